@@ -2,25 +2,174 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/scopweb/mcp-po-compiler-go/internal/mcp"
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
 )
 
 func main() {
+	memoryPath := flag.String("memory", "", "translation memory file fed by compiled and imported catalogs (default: no memory)")
+	glossaryDir := flag.String("glossary-dir", "", "directory holding per-project glossaries (<project>.tbx or <project>.csv)")
+	mtEndpoint := flag.String("mt-endpoint", "", "JSON machine-translation endpoint, registered as the \"http\" pretranslate backend")
+	mtToken := flag.String("mt-token", os.Getenv("MCP_PO_MT_TOKEN"), "bearer token for -mt-endpoint (default $MCP_PO_MT_TOKEN)")
+	concurrency := flag.Int("concurrency", mcp.DefaultConcurrency, "requests handled at the same time")
+	listen := flag.String("listen", "", "serve MCP Streamable HTTP on this address (e.g. 127.0.0.1:8080) instead of stdio")
+	httpToken := flag.String("http-token", os.Getenv("MCP_PO_HTTP_TOKEN"), "bearer token HTTP clients must send (default $MCP_PO_HTTP_TOKEN)")
+	insecureListen := flag.Bool("insecure-listen", false, "allow -listen on an address reachable from other hosts without -http-token")
+	logLevel := flag.String("log-level", "info", "minimum level of server logs: debug, info, warn or error")
+	logFile := flag.String("log-file", "", "append server logs to this file instead of stderr")
+	logFormat := flag.String("log-format", "text", "server log format: text or json")
+	readOnly := flag.Bool("read-only", false, "refuse every tool that writes files or the translation memory; the remaining tools only return results")
+	printManifest := flag.Bool("print-manifest", false, "print the manifest.json describing the server and its tools, then exit")
+	var roots, origins rootList
+	flag.Var(&roots, "root", "workspace directory for file arguments (repeatable; default: the roots advertised by the client)")
+	flag.Var(&origins, "allowed-origin", "browser origin allowed to call the HTTP endpoint besides localhost (repeatable)")
+	flag.Parse()
+
+	if *printManifest {
+		manifestOpts := []mcp.Option{mcp.WithConcurrency(*concurrency)}
+		if *readOnly {
+			manifestOpts = append(manifestOpts, mcp.WithReadOnly())
+		}
+		srv := mcp.NewServer(manifestOpts...)
+		err := srv.WriteManifest(os.Stdout)
+		_ = srv.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot write manifest: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logger, closeLog, err := newLogger(*logFile, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging setup: %v\n", err)
+		os.Exit(2)
+	}
+	defer closeLog()
+	slog.SetDefault(logger)
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	srv := mcp.NewServer()
-	if err := srv.Serve(ctx); err != nil {
-		if err == context.Canceled {
-			log.Println("shutdown requested")
+	svcOpts := []po.Option{po.WithGlossaryDir(*glossaryDir)}
+	if *memoryPath != "" {
+		openMemory := po.OpenMemory
+		if *readOnly {
+			openMemory = po.OpenMemoryReadOnly
+		}
+		memory, err := openMemory(*memoryPath)
+		if err != nil {
+			logger.Error("cannot open translation memory", "path", *memoryPath, "error", err)
+			os.Exit(1)
+		}
+		svcOpts = append(svcOpts, po.WithMemory(memory))
+	}
+	if *mtEndpoint != "" {
+		svcOpts = append(svcOpts, po.WithTranslator("http", po.NewHTTPTranslator(*mtEndpoint, *mtToken)))
+	}
+
+	ws, err := workspace.New(roots...)
+	if err != nil {
+		logger.Error("invalid workspace root", "error", err)
+		os.Exit(1)
+	}
+
+	serverOpts := []mcp.Option{mcp.WithService(po.NewService(svcOpts...)), mcp.WithWorkspace(ws), mcp.WithConcurrency(*concurrency), mcp.WithLogger(logger)}
+	if *listen != "" {
+		if *httpToken == "" && !loopback(*listen) {
+			if !*insecureListen {
+				logger.Error("refusing to serve HTTP to other hosts without a token: set -http-token, listen on a loopback address, or pass -insecure-listen", "listen", *listen)
+				closeLog()
+				os.Exit(2)
+			}
+			logger.Warn("HTTP endpoint is reachable from other hosts and -http-token is not set", "listen", *listen)
+		}
+		transport := mcp.NewHTTPTransport(*listen, *httpToken)
+		transport.AllowedOrigins = origins
+		serverOpts = append(serverOpts, mcp.WithTransport(transport))
+	}
+	if *readOnly {
+		serverOpts = append(serverOpts, mcp.WithReadOnly())
+	}
+
+	srv := mcp.NewServer(serverOpts...)
+	err = srv.Serve(ctx)
+	if cerr := srv.Close(); cerr != nil {
+		logger.Error("cleanup failed", "error", cerr)
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			logger.Info("shutdown requested")
 			return
 		}
-		log.Printf("server stopped with error: %v\n", err)
+		logger.Error("server stopped", "error", err)
+		closeLog()
 		os.Exit(1)
 	}
 }
+
+// newLogger builds the server logger, writing to file (appended) or stderr.
+// The returned func closes the file.
+func newLogger(file, level, format string) (*slog.Logger, func(), error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, nil, fmt.Errorf("-log-level: %w", err)
+	}
+	var w io.Writer = os.Stderr
+	closeFn := func() {}
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		w = f
+		closeFn = func() { _ = f.Close() }
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), closeFn, nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), closeFn, nil
+	default:
+		closeFn()
+		return nil, nil, fmt.Errorf("-log-format: unknown format %q", format)
+	}
+}
+
+// loopback reports whether addr only listens on the local host.
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// rootList collects repeated -root and -allowed-origin flags.
+type rootList []string
+
+func (r *rootList) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *rootList) Set(v string) error {
+	*r = append(*r, v)
+	return nil
+}
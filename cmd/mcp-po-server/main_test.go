@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The stdio transport owns stdout, so the server logger must write to
+// stderr or the log file.
+func TestNewLoggerLeavesStdout(t *testing.T) {
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldOut, oldErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() { os.Stdout, os.Stderr = oldOut, oldErr }()
+
+	logFile := filepath.Join(dir, "server.log")
+	for _, tc := range []struct{ file, format, want string }{
+		{"", "text", filepath.Join(dir, "stderr")},
+		{"", "json", filepath.Join(dir, "stderr")},
+		{logFile, "text", logFile},
+	} {
+		logger, closeLog, err := newLogger(tc.file, "debug", tc.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Debug("logged " + tc.format)
+		closeLog()
+
+		data, err := os.ReadFile(tc.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "logged "+tc.format) {
+			t.Fatalf("%s misses the record logged with -log-file=%q -log-format=%s", tc.want, tc.file, tc.format)
+		}
+	}
+	if info, err := stdout.Stat(); err != nil || info.Size() != 0 {
+		t.Fatalf("the logger wrote to stdout (%v)", err)
+	}
+}
@@ -4,6 +4,7 @@ go 1.25.0
 
 toolchain go1.25.7
 
-require github.com/leonelquinteros/gotext v1.5.2
-
-require golang.org/x/text v0.33.0 // indirect
+require (
+	github.com/leonelquinteros/gotext v1.5.2
+	golang.org/x/text v0.33.0
+)
@@ -0,0 +1,34 @@
+package mcp
+
+// stringArg returns a string argument, or "" when missing or mistyped.
+func stringArg(args map[string]any, name string) string {
+	v, _ := args[name].(string)
+	return v
+}
+
+// boolArg returns a boolean argument, or false when missing or mistyped.
+func boolArg(args map[string]any, name string) bool {
+	v, _ := args[name].(bool)
+	return v
+}
+
+// stringSliceArg returns an array-of-strings argument. A single string is
+// accepted as a one-element list.
+func stringSliceArg(args map[string]any, name string) []string {
+	switch v := args[name].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
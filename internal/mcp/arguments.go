@@ -0,0 +1,262 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Tool arguments are checked against the inputSchema of the tool before it
+// runs. The checker covers the JSON Schema subset the tool definitions use:
+// type, properties, required, items, enum, minimum, maximum and default.
+
+// argumentError lists every problem found in the arguments of a call, so a
+// client can fix them all at once.
+type argumentError struct {
+	problems []string
+}
+
+func (e *argumentError) Error() string {
+	return "invalid arguments: " + strings.Join(e.problems, "; ")
+}
+
+// checkArguments validates the arguments of a tool call against the tool
+// inputSchema, in place: unknown arguments are rejected, null ones are
+// treated as missing, missing ones get their schema default, and a single
+// string is accepted for an array of strings. A required content argument
+// must be given either as content or by its path variant.
+func checkArguments(t *Tool, args map[string]any) error {
+	var problems []string
+	checkObject("", t.InputSchema, args, &problems)
+	props, _ := t.InputSchema["properties"].(map[string]any)
+	for _, pa := range t.eitherRequired {
+		_, content := args[pa.content]
+		_, path := args[pa.path]
+		if !content && !path {
+			problems = append(problems, fmt.Sprintf("%s: required %s is missing (or pass %s)", pa.content, typeName(props[pa.content]), pa.path))
+		}
+	}
+	if len(problems) > 0 {
+		return &argumentError{problems: problems}
+	}
+	return nil
+}
+
+func checkObject(path string, schema map[string]any, obj map[string]any, problems *[]string) {
+	props, _ := schema["properties"].(map[string]any)
+
+	var unknown []string
+	for name := range obj {
+		if _, ok := props[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	kind := "argument"
+	if path != "" {
+		kind = "field"
+	}
+	for _, name := range unknown {
+		known := make([]string, 0, len(props))
+		for p := range props {
+			known = append(known, p)
+		}
+		sort.Strings(known)
+		*problems = append(*problems, fmt.Sprintf("%s: unknown %s (expected one of %s)", join(path, name), kind, strings.Join(known, ", ")))
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, _ := props[name].(map[string]any)
+		v, ok := obj[name]
+		if ok && v == nil {
+			delete(obj, name)
+			ok = false
+		}
+		if !ok {
+			if def, has := prop["default"]; has {
+				obj[name] = jsonValue(def)
+			}
+			continue
+		}
+		obj[name] = checkValue(join(path, name), prop, v, problems)
+	}
+
+	for _, name := range requiredList(schema) {
+		if _, ok := obj[name]; !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: required %s is missing", join(path, name), typeName(props[name])))
+		}
+	}
+}
+
+// checkValue validates v and returns it, converted when the schema allows a
+// shorthand.
+func checkValue(path string, schema map[string]any, v any, problems *[]string) any {
+	typ, _ := schema["type"].(string)
+	mismatch := func() any {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, typeName(schema), jsonType(v)))
+		return v
+	}
+
+	switch typ {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return mismatch()
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch()
+		}
+	case "integer", "number":
+		f, ok := v.(float64)
+		if !ok || (typ == "integer" && f != math.Trunc(f)) {
+			return mismatch()
+		}
+		if min, ok := number(schema["minimum"]); ok && f < min {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at least %v, got %v", path, min, f))
+		}
+		if max, ok := number(schema["maximum"]); ok && f > max {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at most %v, got %v", path, max, f))
+		}
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		list, ok := v.([]any)
+		if s, isString := v.(string); isString && items["type"] == "string" {
+			list, ok = []any{}, true
+			if s != "" {
+				list = append(list, s)
+			}
+		}
+		if !ok {
+			return mismatch()
+		}
+		for i, item := range list {
+			list[i] = checkValue(fmt.Sprintf("%s[%d]", path, i), items, item, problems)
+		}
+		v = list
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return mismatch()
+		}
+		checkObject(path, schema, obj, problems)
+	}
+
+	if enum := enumValues(schema); len(enum) > 0 && !slices.Contains(enum, v) {
+		quoted := make([]string, len(enum))
+		for i, e := range enum {
+			data, _ := json.Marshal(e)
+			quoted[i] = string(data)
+		}
+		data, _ := json.Marshal(v)
+		*problems = append(*problems, fmt.Sprintf("%s: must be one of %s, got %s", path, strings.Join(quoted, ", "), data))
+	}
+	return v
+}
+
+// typeName describes the type a schema expects, e.g. "array of string".
+func typeName(schema any) string {
+	s, _ := schema.(map[string]any)
+	typ, _ := s["type"].(string)
+	if typ == "" {
+		return "value"
+	}
+	if typ == "array" {
+		if items, ok := s["items"].(map[string]any); ok {
+			return "array of " + typeName(items)
+		}
+	}
+	return typ
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func requiredList(schema map[string]any) []string {
+	switch r := schema["required"].(type) {
+	case []string:
+		return r
+	case []any:
+		out := make([]string, 0, len(r))
+		for _, v := range r {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func enumValues(schema map[string]any) []any {
+	switch e := schema["enum"].(type) {
+	case []string:
+		out := make([]any, len(e))
+		for i, v := range e {
+			out[i] = v
+		}
+		return out
+	case []any:
+		return e
+	}
+	return nil
+}
+
+// number converts a schema bound, written as an int or a float, to float64.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// jsonValue returns v as encoding/json would decode it, so that defaults
+// look like arguments sent by a client.
+func jsonValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if json.Unmarshal(data, &out) != nil {
+		return v
+	}
+	return out
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
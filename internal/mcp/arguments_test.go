@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeArguments(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var args map[string]any
+	if err := json.Unmarshal([]byte(s), &args); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return args
+}
+
+// check validates args against the inputSchema of a built-in tool.
+func check(t *testing.T, tool string, args map[string]any) error {
+	t.Helper()
+	def, ok := NewServer().tool(tool)
+	if !ok {
+		t.Fatalf("no tool %s", tool)
+	}
+	return checkArguments(def, args)
+}
+
+func TestCheckArgumentsDefaults(t *testing.T) {
+	args := decodeArguments(t, `{"po_content":"x","mirror":null}`)
+	if err := check(t, "pseudolocalize_po", args); err != nil {
+		t.Fatalf("checkArguments: %v", err)
+	}
+	want := map[string]any{"po_content": "x", "backup": false, "dry_run": false, "accents": true, "brackets": true, "mirror": false, "expansion": 0.3}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("arguments = %v, want %v", args, want)
+	}
+
+	args = decodeArguments(t, `{"po_content":"x"}`)
+	if err := check(t, "suggest_translations", args); err != nil {
+		t.Fatalf("checkArguments: %v", err)
+	}
+	if args["limit"] != float64(3) {
+		t.Fatalf("limit default = %#v, want float64(3) as decoded from JSON", args["limit"])
+	}
+}
+
+func TestCheckArgumentsStringList(t *testing.T) {
+	args := decodeArguments(t, `{"po_content":"x","states":"fuzzy"}`)
+	if err := check(t, "list_entries", args); err != nil {
+		t.Fatalf("checkArguments: %v", err)
+	}
+	if !reflect.DeepEqual(args["states"], []any{"fuzzy"}) {
+		t.Fatalf("states = %#v, want a one-element list", args["states"])
+	}
+}
+
+func TestCheckArgumentsErrors(t *testing.T) {
+	cases := []struct {
+		tool, args string
+		want       []string
+	}{
+		{"compile_po", `{"po_content":true}`, []string{"po_content: expected string, got boolean"}},
+		{"compile_po", `{"po_content":"x","return":"file"}`, []string{`return: must be one of "base64", "path", got "file"`}},
+		{"summarize_po", `{"po_contnet":"x"}`, []string{"po_contnet: unknown argument (expected one of po_content, po_path)"}},
+		{"init_po", `{"pot_content":"x"}`, []string{"locale: required string is missing"}},
+		{"suggest_translations", `{"po_content":"x","min_score":1.5,"limit":0}`, []string{
+			"limit: must be at least 1, got 0",
+			"min_score: must be at most 1, got 1.5",
+		}},
+		{"list_entries", `{"po_content":"x","limit":2.5,"states":[1]}`, []string{
+			"limit: expected integer, got number",
+			"states[0]: expected string, got integer",
+		}},
+		{"update_entries", `{"po_content":"x","edits":[{"msgid":"a","nope":1},"b"]}`, []string{
+			"edits[0].nope: unknown field",
+			"edits[1]: expected object, got string",
+		}},
+	}
+	for _, c := range cases {
+		err := check(t, c.tool, decodeArguments(t, c.args))
+		if err == nil {
+			t.Fatalf("%s %s: no error, want %q", c.tool, c.args, c.want)
+		}
+		for _, w := range c.want {
+			if !strings.Contains(err.Error(), w) {
+				t.Fatalf("%s %s: error %q does not contain %q", c.tool, c.args, err, w)
+			}
+		}
+	}
+}
+
+// The checker only understands part of JSON Schema; a tool schema using
+// anything else would be checked partially without anyone noticing.
+func TestToolSchemasUseSupportedKeywords(t *testing.T) {
+	supported := map[string]bool{
+		"type": true, "properties": true, "required": true, "items": true,
+		"enum": true, "minimum": true, "maximum": true, "default": true, "description": true,
+	}
+	var walk func(path string, schema map[string]any)
+	walk = func(path string, schema map[string]any) {
+		for k, v := range schema {
+			if !supported[k] {
+				t.Fatalf("%s: unsupported schema keyword %q", path, k)
+			}
+			switch k {
+			case "properties":
+				for name, prop := range v.(map[string]any) {
+					walk(path+"."+name, prop.(map[string]any))
+				}
+			case "items":
+				walk(path+"[]", v.(map[string]any))
+			}
+		}
+		if def, ok := schema["default"]; ok {
+			var problems []string
+			checkValue(path, schema, jsonValue(def), &problems)
+			if len(problems) > 0 {
+				t.Fatalf("%s: default does not match the schema: %v", path, problems)
+			}
+		}
+	}
+	for _, tool := range NewServer().tools {
+		walk(tool.Name, tool.InputSchema)
+	}
+}
@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Requests sent by the server to the client (sampling, roots...) are
+// correlated with their responses through the session's pending map, keyed by
+// request id.
+
+type jsonRPCOutgoing struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// jsonRPCNotification is a message to the client that expects no response.
+type jsonRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// jsonRPCReply is a client response to a server-initiated request.
+type jsonRPCReply struct {
+	ID     any             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *rpcError       `json:"error,omitempty"`
+}
+
+// clientError is a JSON-RPC error returned by the client.
+type clientError struct {
+	method string
+	err    *rpcError
+}
+
+func (e *clientError) Error() string {
+	return fmt.Sprintf("client rejected %s: %s (code %d)", e.method, e.err.Message, e.err.Code)
+}
+
+// messageWriter delivers one JSON-RPC message to the client.
+type messageWriter func(msg any) error
+
+// session is the state of one client connection: the negotiated protocol, the
+// client capabilities and the requests the server sent to the client.
+type session struct {
+	id string
+	// out delivers the messages that are not sent while handling a client
+	// request.
+	out messageWriter
+	// clientRoots lets the roots the client advertises confine the
+	// workspace. The workspace is shared by every session, so only
+	// single-client transports enable it.
+	clientRoots bool
+
+	mu         sync.Mutex
+	protocol   string
+	clientCaps map[string]any
+	pending    map[string]chan jsonRPCReply
+	nextID     int64
+	// logLevel is the minimum level of the log messages sent to the
+	// client; nil until it calls logging/setLevel.
+	logLevel *slog.Level
+	// inflight cancels the client requests being handled, keyed by their
+	// JSON-encoded id.
+	inflight map[string]context.CancelCauseFunc
+	// closed is closed once the client can no longer answer.
+	closed chan struct{}
+}
+
+type contextKey int
+
+const (
+	sessionKey contextKey = iota
+	writerKey
+	dryRunKey
+)
+
+// withSession attaches the session a request belongs to, and the writer for
+// the messages sent while handling it, to ctx.
+func withSession(ctx context.Context, sess *session, out messageWriter) context.Context {
+	ctx = context.WithValue(ctx, sessionKey, sess)
+	return context.WithValue(ctx, writerKey, out)
+}
+
+// withDryRun marks the tool call in ctx as a dry run: besides its output
+// file, it must not change anything the server keeps, such as the memory.
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey, true)
+}
+
+// isDryRun reports whether ctx belongs to a dry run.
+func isDryRun(ctx context.Context) bool {
+	dry, _ := ctx.Value(dryRunKey).(bool)
+	return dry
+}
+
+// sessionFrom returns the session of ctx, or an empty one outside a request.
+func sessionFrom(ctx context.Context) *session {
+	if sess, ok := ctx.Value(sessionKey).(*session); ok {
+		return sess
+	}
+	return &session{}
+}
+
+// writerFrom returns the writer of the request in ctx, falling back to the
+// session's.
+func writerFrom(ctx context.Context) messageWriter {
+	if out, ok := ctx.Value(writerKey).(messageWriter); ok && out != nil {
+		return out
+	}
+	if out := sessionFrom(ctx).out; out != nil {
+		return out
+	}
+	return func(any) error { return errors.New("no connection to the client") }
+}
+
+// call sends a request to the client of ctx and waits for its response,
+// decoding the result into out.
+func (s *Server) call(ctx context.Context, method string, params any, out any) error {
+	sess := sessionFrom(ctx)
+	sess.mu.Lock()
+	sess.nextID++
+	id := fmt.Sprintf("srv-%d", sess.nextID)
+	ch := make(chan jsonRPCReply, 1)
+	if sess.pending == nil {
+		sess.pending = make(map[string]chan jsonRPCReply)
+	}
+	sess.pending[id] = ch
+	sess.mu.Unlock()
+
+	defer func() {
+		sess.mu.Lock()
+		delete(sess.pending, id)
+		sess.mu.Unlock()
+	}()
+
+	if err := writerFrom(ctx)(jsonRPCOutgoing{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		return fmt.Errorf("send %s: %w", method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-sess.done():
+		return errClientGone
+	case reply := <-ch:
+		if reply.Error != nil {
+			return &clientError{method: method, err: reply.Error}
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(reply.Result, out); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+		return nil
+	}
+}
+
+// done is closed when the session is.
+func (sess *session) done() <-chan struct{} {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.closed == nil {
+		sess.closed = make(chan struct{})
+	}
+	return sess.closed
+}
+
+// close fails the calls waiting on the client, which will not answer them.
+func (sess *session) close() {
+	done := sess.done()
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	select {
+	case <-done:
+	default:
+		close(sess.closed)
+	}
+}
+
+// handleReply routes a client response to the call waiting for it. Replies
+// to unknown ids are dropped.
+func (sess *session) handleReply(reply jsonRPCReply) {
+	id, ok := reply.ID.(string)
+	if !ok {
+		return
+	}
+	sess.mu.Lock()
+	ch := sess.pending[id]
+	sess.mu.Unlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- reply:
+	default: // duplicate reply
+	}
+}
+
+// cancel aborts the client request with the given id, if it is still being
+// handled. Unknown ids are ignored: the request may just have completed.
+func (sess *session) cancel(id any) {
+	sess.mu.Lock()
+	cancel := sess.inflight[requestKey(id)]
+	sess.mu.Unlock()
+	if cancel != nil {
+		cancel(errRequestCancelled)
+	}
+}
+
+// requestKey identifies a request id, keeping 1 and "1" apart.
+func requestKey(id any) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// clientSupports reports whether the client of ctx declared a capability
+// during initialize.
+func clientSupports(ctx context.Context, capability string) bool {
+	sess := sessionFrom(ctx)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	_, ok := sess.clientCaps[capability]
+	return ok
+}
+
+var errClientGone = errors.New("the client closed the connection")
+
+var errNoSampling = errors.New("client does not support sampling (sampling/createMessage)")
@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
+)
+
+// buildCacheFile records, in the searched directory, the content hash and
+// outputs of every catalog compiled by compile_dir, so unchanged catalogs
+// whose outputs are all still there are skipped.
+const buildCacheFile = ".mcp-po-build.json"
+
+// buildCacheVersion is bumped when the cache layout changes; caches of other
+// versions are ignored.
+const buildCacheVersion = 2
+
+const (
+	defaultCompileWorkers = 4
+	maxCompileWorkers     = 16
+)
+
+type buildCache struct {
+	Version int                    `json:"version"`
+	Files   map[string]cachedBuild `json:"files"` // path relative to the cache -> build
+}
+
+// cachedBuild is the last build of a catalog: the hash of its content and
+// build options, and the files written, relative to the cache.
+type cachedBuild struct {
+	Hash    string   `json:"hash"`
+	Outputs []string `json:"outputs"`
+}
+
+type compileDirFile struct {
+	Path     string      `json:"path"`
+	Status   string      `json:"status"` // compiled, would_compile, unchanged or failed
+	Outputs  []string    `json:"outputs,omitempty"`
+	Stats    *po.Summary `json:"stats,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Error    string      `json:"error,omitempty"`
+
+	hash string
+}
+
+type compileDirResult struct {
+	Files     []compileDirFile `json:"files"`
+	Compiled  int              `json:"compiled"`
+	Unchanged int              `json:"unchanged"`
+	Failed    int              `json:"failed"`
+	DryRun    bool             `json:"dry_run,omitempty"`
+	// Unreadable lists the directories that could not be searched.
+	Unreadable []string `json:"unreadable,omitempty"`
+}
+
+// compileDir compiles every catalog under a directory or glob with a bounded
+// worker pool, writing .mo (and optionally .json and .l10n.php) siblings.
+// A dry run still compiles, to report failures, but writes nothing.
+func (s *Server) compileDir(ctx context.Context, a compileDirArgs) (*compileDirResult, error) {
+	found, err := s.workspace.Find(a.Path, ".po")
+	if err != nil {
+		return nil, err
+	}
+	base, files := found.Base, found.Files
+	opts := po.BuildOptions{JSON: a.JSON, PHP: a.PHP, DryRun: a.DryRun}
+	workers := a.Workers
+	if workers <= 0 {
+		workers = defaultCompileWorkers
+	}
+	workers = min(workers, maxCompileWorkers)
+
+	cache := s.loadBuildCache(base)
+	force := a.Force
+
+	res := &compileDirResult{Files: make([]compileDirFile, len(files)), DryRun: a.DryRun}
+	for _, dir := range found.Unreadable {
+		rel, _ := filepath.Rel(base, dir)
+		res.Unreadable = append(res.Unreadable, filepath.ToSlash(rel))
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var done atomic.Int64
+	for range min(workers, max(len(files), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res.Files[i] = s.compileFile(ctx, base, files[i], opts, cache, force, a.DryRun)
+				n := int(done.Add(1))
+				po.ReportProgress(ctx, n, len(files), res.Files[i].Path+": "+res.Files[i].Status)
+			}
+		}()
+	}
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, f := range res.Files {
+		switch f.Status {
+		case "compiled":
+			res.Compiled++
+			cache.Files[f.Path] = cachedBuild{Hash: f.hash, Outputs: f.Outputs}
+			changed = true
+		case "would_compile":
+			res.Compiled++
+		case "unchanged":
+			res.Unchanged++
+		default:
+			res.Failed++
+		}
+	}
+	if changed {
+		// The cache only saves work; failing to store it is not an error.
+		if data, err := json.MarshalIndent(cache, "", "  "); err == nil {
+			_, _ = s.workspace.Write(filepath.Join(base, buildCacheFile), data, workspace.WriteOptions{})
+		}
+	}
+	return res, nil
+}
+
+// compileFile builds one catalog; paths in the report are relative to base.
+// With dryRun it only reports the files it would write.
+func (s *Server) compileFile(ctx context.Context, base, file string, opts po.BuildOptions, cache *buildCache, force, dryRun bool) compileDirFile {
+	rel, _ := filepath.Rel(base, file)
+	out := compileDirFile{Path: filepath.ToSlash(rel)}
+	fail := func(err error) compileDirFile {
+		out.Status = "failed"
+		out.Error = err.Error()
+		return out
+	}
+
+	data, err := s.workspace.Read(file)
+	if err != nil {
+		return fail(err)
+	}
+	stem := strings.TrimSuffix(file, filepath.Ext(file))
+	out.hash = po.ContentHash(string(data) + buildOptionsKey(opts))
+	if prev, ok := cache.Files[out.Path]; ok && !force && prev.Hash == out.hash && s.outputsExist(base, prev.Outputs) {
+		out.Status = "unchanged"
+		return out
+	}
+
+	built, err := s.po.Build(ctx, string(data), opts)
+	if err != nil {
+		return fail(err)
+	}
+	outputs := map[string][]byte{stem + ".mo": built.MO}
+	for sum, doc := range built.JSON {
+		outputs[stem+"-"+sum+".json"] = doc
+	}
+	if built.PHP != nil {
+		outputs[stem+".l10n.php"] = built.PHP
+	}
+	for path, content := range outputs {
+		var real string
+		if dryRun {
+			real, err = s.workspace.Resolve(path)
+		} else {
+			var written *workspace.Written
+			written, err = s.workspace.Write(path, content, workspace.WriteOptions{})
+			if written != nil {
+				real = written.Path
+			}
+		}
+		if err != nil {
+			return fail(err)
+		}
+		rel, _ := filepath.Rel(base, real)
+		out.Outputs = append(out.Outputs, filepath.ToSlash(rel))
+	}
+	sort.Strings(out.Outputs)
+	out.Status = "compiled"
+	if dryRun {
+		out.Status = "would_compile"
+	}
+	out.Stats = &built.Stats
+	out.Warnings = built.Warnings
+	return out
+}
+
+// outputsExist reports whether every output of a cached build, relative to
+// base, is still there.
+func (s *Server) outputsExist(base string, outputs []string) bool {
+	for _, o := range outputs {
+		if !s.workspace.IsFile(filepath.Join(base, filepath.FromSlash(o))) {
+			return false
+		}
+	}
+	return len(outputs) > 0
+}
+
+func buildOptionsKey(opts po.BuildOptions) string {
+	key := "|mo"
+	if opts.JSON {
+		key += ",json"
+	}
+	if opts.PHP {
+		key += ",php"
+	}
+	return key
+}
+
+func (s *Server) loadBuildCache(base string) *buildCache {
+	cache := &buildCache{Version: buildCacheVersion, Files: map[string]cachedBuild{}}
+	data, err := s.workspace.Read(filepath.Join(base, buildCacheFile))
+	if err != nil {
+		return cache
+	}
+	var stored buildCache
+	if json.Unmarshal(data, &stored) == nil && stored.Version == buildCacheVersion && stored.Files != nil {
+		return &stored
+	}
+	return cache
+}
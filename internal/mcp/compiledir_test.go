@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompileDirSkipsUnchanged(t *testing.T) {
+	s, dir := workspaceServer(t)
+	status := func(args map[string]any) string {
+		t.Helper()
+		res := call(t, s, "compile_dir", args)
+		if res.IsError {
+			t.Fatalf("compile_dir: %s", res.Content[0].Text)
+		}
+		return res.StructuredContent["files"].([]any)[0].(map[string]any)["status"].(string)
+	}
+	php := map[string]any{"path": ".", "php": true}
+
+	if got := status(php); got != "compiled" {
+		t.Fatalf("first build = %s, want compiled", got)
+	}
+	if got := status(php); got != "unchanged" {
+		t.Fatalf("second build = %s, want unchanged", got)
+	}
+
+	// A missing output is rebuilt even though the .mo is still there.
+	if err := os.Remove(filepath.Join(dir, "de_DE.l10n.php")); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(php); got != "compiled" {
+		t.Fatalf("build without the .l10n.php = %s, want compiled", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "de_DE.l10n.php")); err != nil {
+		t.Fatalf("the .l10n.php was not written again: %v", err)
+	}
+
+	// Other options are another build.
+	if got := status(map[string]any{"path": "."}); got != "compiled" {
+		t.Fatalf("build without php = %s, want compiled", got)
+	}
+	if got := status(map[string]any{"path": ".", "force": true}); got != "compiled" {
+		t.Fatalf("forced build = %s, want compiled", got)
+	}
+}
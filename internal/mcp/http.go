@@ -0,0 +1,463 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Streamable HTTP headers.
+const (
+	sessionHeader  = "Mcp-Session-Id"
+	protocolHeader = "MCP-Protocol-Version"
+)
+
+const (
+	// DefaultHTTPPath is the endpoint path of the HTTP transport.
+	DefaultHTTPPath = "/mcp"
+	// DefaultSessionTimeout is how long an idle HTTP session is kept.
+	DefaultSessionTimeout = time.Hour
+
+	maxHTTPSessions = 256
+	maxHTTPBody     = 64 << 20
+	streamKeepAlive = 30 * time.Second
+)
+
+// HTTPTransport serves clients over MCP Streamable HTTP. Clients POST
+// JSON-RPC messages to one endpoint; a request gets a JSON response, or an
+// SSE stream for tool calls so that sampling requests and notifications can
+// precede the result. A GET opens a stream for messages not tied to a
+// request, and DELETE ends the session.
+//
+// Sessions start with initialize, whose response carries the Mcp-Session-Id
+// header every later request must send. Browser requests from an Origin
+// other than localhost or AllowedOrigins are rejected to prevent DNS
+// rebinding, and with Token set every request needs "Authorization: Bearer
+// <Token>".
+//
+// The workspace is shared by all sessions, so client roots are ignored: the
+// workspace holds only the roots the server was started with.
+type HTTPTransport struct {
+	Addr           string
+	Path           string
+	Token          string
+	AllowedOrigins []string
+	SessionTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// httpSession is a session with its optional GET stream.
+type httpSession struct {
+	*session
+	lastSeen time.Time // guarded by HTTPTransport.mu
+
+	streamMu sync.Mutex
+	stream   chan []byte
+}
+
+// NewHTTPTransport returns a transport listening on addr. An empty token
+// disables authentication.
+func NewHTTPTransport(addr, token string) *HTTPTransport {
+	return &HTTPTransport{Addr: addr, Token: token}
+}
+
+// Serve implements Transport.
+func (t *HTTPTransport) Serve(ctx context.Context, s *Server) error {
+	ln, err := net.Listen("tcp", t.Addr)
+	if err != nil {
+		return err
+	}
+	path := t.Path
+	if path == "" {
+		path = DefaultHTTPPath
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, t.Handler(s))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := srv.Shutdown(shutdown); err != nil {
+					_ = srv.Close()
+				}
+				return
+			case <-done:
+				return
+			case now := <-ticker.C:
+				t.expire(now)
+			}
+		}
+	}()
+
+	s.logger.Info("listening", "transport", "http", "url", "http://"+ln.Addr().String()+path)
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Handler returns the http.Handler of the MCP endpoint, for use with a
+// caller-owned http.Server.
+func (t *HTTPTransport) Handler(s *Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !t.originAllowed(r.Header.Get("Origin")) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if !t.authorized(r.Header.Get("Authorization")) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if v := r.Header.Get(protocolHeader); v != "" && !slices.Contains(supportedProtocolVersions, v) {
+			http.Error(w, "unsupported protocol version "+v, http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			t.handlePost(w, r, s)
+		case http.MethodGet:
+			t.handleGet(w, r)
+		case http.MethodDelete:
+			if sess := t.lookup(w, r); sess != nil {
+				t.mu.Lock()
+				delete(t.sessions, sess.id)
+				t.mu.Unlock()
+				sess.close()
+				s.logger.Info("session ended", "session", sess.id)
+				w.WriteHeader(http.StatusNoContent)
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (t *HTTPTransport) handlePost(w http.ResponseWriter, r *http.Request, s *Server) {
+	body, err := readBody(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	msgs, batch, resp := decodeMessages(body)
+	if resp != nil {
+		s.logger.Warn("invalid message", "transport", "http", "input_bytes", len(body), "error", resp.Error.Message)
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+	if batch {
+		sess := t.lookup(w, r)
+		if sess == nil {
+			return
+		}
+		// Messages sent while handling a batch go to the GET stream.
+		if resps := s.handleBatch(r.Context(), sess.session, msgs, nil); len(resps) > 0 {
+			writeJSON(w, http.StatusOK, resps)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	req := msgs[0].req
+	if req == nil {
+		if msgs[0].err == nil {
+			// An invalid notification is dropped.
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, msgs[0].err)
+		return
+	}
+
+	if req.Method == "initialize" {
+		t.initialize(w, r, s, req)
+		return
+	}
+	sess := t.lookup(w, r)
+	if sess == nil {
+		return
+	}
+
+	if req.isNotification() || req.isReply() {
+		s.handle(r.Context(), sess.session, req, nil)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if req.Method == "tools/call" && accepts(r, "text/event-stream") {
+		// Tool calls may send sampling requests and notifications before
+		// their result: answer with an SSE stream.
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		var mu sync.Mutex
+		out := func(msg any) error {
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return writeEvent(w, data)
+		}
+		if resp := s.handle(r.Context(), sess.session, req, out); resp != nil {
+			_ = out(resp)
+		}
+		return
+	}
+
+	resp = s.handle(r.Context(), sess.session, req, nil)
+	if resp == nil {
+		// Cancelled by the client: no response is due.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// initialize starts a session. It is registered only once initialize
+// succeeded.
+func (t *HTTPTransport) initialize(w http.ResponseWriter, r *http.Request, s *Server, req *jsonRPCRequest) {
+	t.mu.Lock()
+	full := len(t.sessions) >= maxHTTPSessions
+	t.mu.Unlock()
+	if full {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "too many sessions", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	hs := &httpSession{lastSeen: time.Now()}
+	hs.session = &session{id: id, out: hs.push}
+	resp := s.handle(r.Context(), hs.session, req, nil)
+	if resp != nil && resp.Error == nil {
+		t.mu.Lock()
+		if t.sessions == nil {
+			t.sessions = make(map[string]*httpSession)
+		}
+		t.sessions[id] = hs
+		t.mu.Unlock()
+		s.logger.Info("session started", "session", id, "remote", r.RemoteAddr)
+		w.Header().Set(sessionHeader, id)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGet streams the messages of a session that are not tied to a
+// request. A session has at most one such stream.
+func (t *HTTPTransport) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !accepts(r, "text/event-stream") {
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	sess := t.lookup(w, r)
+	if sess == nil {
+		return
+	}
+	stream := make(chan []byte, 16)
+	sess.streamMu.Lock()
+	if sess.stream != nil {
+		sess.streamMu.Unlock()
+		http.Error(w, "the session already has a stream", http.StatusConflict)
+		return
+	}
+	sess.stream = stream
+	sess.streamMu.Unlock()
+	defer func() {
+		sess.streamMu.Lock()
+		sess.stream = nil
+		sess.streamMu.Unlock()
+		t.mu.Lock()
+		sess.lastSeen = time.Now()
+		t.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-stream:
+			if writeEvent(w, data) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flush(w)
+		}
+	}
+}
+
+// push queues a message on the session's GET stream.
+func (hs *httpSession) push(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	hs.streamMu.Lock()
+	stream := hs.stream
+	hs.streamMu.Unlock()
+	if stream == nil {
+		return errors.New("the client has no open stream (GET) for this session")
+	}
+	select {
+	case stream <- data:
+		return nil
+	case <-time.After(5 * time.Second):
+		return errors.New("the client stream is not being read")
+	}
+}
+
+// lookup returns the session named by the request, answering the request
+// with an error when there is none.
+func (t *HTTPTransport) lookup(w http.ResponseWriter, r *http.Request) *httpSession {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		http.Error(w, "missing "+sessionHeader+" header; start with initialize", http.StatusBadRequest)
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sess := t.sessions[id]
+	if sess == nil {
+		http.Error(w, "unknown or expired session", http.StatusNotFound)
+		return nil
+	}
+	sess.lastSeen = time.Now()
+	return sess
+}
+
+// expire drops the sessions idle for longer than the session timeout.
+func (t *HTTPTransport) expire(now time.Time) {
+	timeout := t.SessionTimeout
+	if timeout <= 0 {
+		timeout = DefaultSessionTimeout
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, sess := range t.sessions {
+		sess.streamMu.Lock()
+		streaming := sess.stream != nil
+		sess.streamMu.Unlock()
+		if !streaming && now.Sub(sess.lastSeen) > timeout {
+			delete(t.sessions, id)
+			sess.close()
+		}
+	}
+}
+
+// originAllowed accepts requests without Origin (not from a browser), from
+// localhost, and from the configured origins.
+func (t *HTTPTransport) originAllowed(origin string) bool {
+	if origin == "" || slices.Contains(t.AllowedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (t *HTTPTransport) authorized(header string) bool {
+	if t.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBody))
+	if err != nil {
+		return nil, fmt.Errorf("request body: %w", err)
+	}
+	return data, nil
+}
+
+// accepts reports whether the Accept header of r lists mediaType.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			mt, _, _ := strings.Cut(part, ";")
+			if strings.TrimSpace(mt) == mediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, msg any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(msg)
+}
+
+func writeEvent(w http.ResponseWriter, data []byte) error {
+	if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	flush(w)
+	return nil
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	s := NewServer(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	t.Cleanup(func() { _ = s.Close() })
+	transport := NewHTTPTransport("", "secret")
+	ts := httptest.NewServer(transport.Handler(s))
+	t.Cleanup(ts.Close)
+
+	post := func(body, session, token, origin string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if session != "" {
+			req.Header.Set(sessionHeader, session)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+	const initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{}}}`
+
+	if resp := post(initialize, "", "wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad token: status %d, want 401", resp.StatusCode)
+	}
+	if resp := post(initialize, "", "secret", "http://evil.example"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin: status %d, want 403", resp.StatusCode)
+	}
+
+	resp := post(initialize, "", "secret", "http://localhost:3000")
+	session := resp.Header.Get(sessionHeader)
+	if resp.StatusCode != http.StatusOK || session == "" {
+		t.Fatalf("initialize: status %d, session %q", resp.StatusCode, session)
+	}
+
+	if resp := post(`{"jsonrpc":"2.0","id":2,"method":"ping"}`, "", "secret", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("missing session: status %d, want 400", resp.StatusCode)
+	}
+	if resp := post(`{"jsonrpc":"2.0","id":2,"method":"ping"}`, "unknown", "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown session: status %d, want 404", resp.StatusCode)
+	}
+	if resp := post(`{"jsonrpc":"2.0","method":"notifications/initialized"}`, session, "secret", ""); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("notification: status %d, want 202", resp.StatusCode)
+	}
+
+	resp = post(`[{"jsonrpc":"2.0","id":3,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":4,"method":"nope"}]`, session, "secret", "")
+	var batch []jsonRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		t.Fatalf("batch response: %v", err)
+	}
+	if len(batch) != 2 || batch[0].ID != float64(3) || batch[0].Error != nil || batch[1].Error == nil || batch[1].Error.Code != errMethodNotFound {
+		t.Fatalf("unexpected batch response: %+v", batch)
+	}
+
+	resp = post(`{"jsonrpc":"2.0","id":5,"method":`, session, "secret", "")
+	var parseErr map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&parseErr); err != nil {
+		t.Fatalf("parse error response: %v", err)
+	}
+	if id, ok := parseErr["id"]; resp.StatusCode != http.StatusBadRequest || !ok || id != nil {
+		t.Fatalf("parse error: status %d, body %v; want 400 with a null id", resp.StatusCode, parseErr)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(sessionHeader, session)
+	del, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_ = del.Body.Close()
+	if del.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: status %d, want 204", del.StatusCode)
+	}
+	if resp := post(`{"jsonrpc":"2.0","id":6,"method":"ping"}`, session, "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted session: status %d, want 404", resp.StatusCode)
+	}
+}
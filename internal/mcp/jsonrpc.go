@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	errParse          = -32700
+	errInvalidRequest = -32600
+	errMethodNotFound = -32601
+	errInvalidParams  = -32602
+	errInternal       = -32603
+)
+
+// UnmarshalJSON records whether the message has an id: a request with
+// "id": null must be answered, a notification, which has none, must not.
+func (r *jsonRPCRequest) UnmarshalJSON(data []byte) error {
+	type plain jsonRPCRequest
+	var msg struct {
+		plain
+		RawID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*r = jsonRPCRequest(msg.plain)
+	r.hasID = len(msg.RawID) > 0
+	r.ID = nil
+	if r.hasID {
+		if err := json.Unmarshal(msg.RawID, &r.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNotification reports whether the message expects no response.
+func (r *jsonRPCRequest) isNotification() bool {
+	return !r.hasID
+}
+
+// isReply reports whether the message is a client response to a request of
+// the server.
+func (r *jsonRPCRequest) isReply() bool {
+	return r.Method == "" && (r.Result != nil || r.Error != nil)
+}
+
+// incoming is one decoded message: a request, notification or reply to
+// handle, or the error response an invalid message gets right away.
+type incoming struct {
+	req *jsonRPCRequest
+	err *jsonRPCResponse
+}
+
+// decodeMessages parses a JSON-RPC payload: one message or a batch array.
+// A payload that is not JSON is answered with a parse error, an empty batch
+// with an invalid request error, both with a null id.
+func decodeMessages(data []byte) (msgs []incoming, batch bool, resp *jsonRPCResponse) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		return nil, false, errorResponse(nil, errParse, "Parse error")
+	}
+	if data[0] != '[' {
+		return []incoming{decodeMessage(data)}, false, nil
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil || len(raws) == 0 {
+		return nil, true, errorResponse(nil, errInvalidRequest, "Invalid Request: empty batch")
+	}
+	for _, raw := range raws {
+		msgs = append(msgs, decodeMessage(raw))
+	}
+	return msgs, true, nil
+}
+
+// decodeMessage validates the envelope of one message.
+func decodeMessage(data json.RawMessage) incoming {
+	if d := bytes.TrimSpace(data); len(d) == 0 || d[0] != '{' {
+		return incoming{err: errorResponse(nil, errInvalidRequest, "Invalid Request: a message must be an object")}
+	}
+	var req jsonRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return incoming{err: errorResponse(nil, errInvalidRequest, "Invalid Request: malformed message")}
+	}
+	switch req.ID.(type) {
+	case nil, string, float64:
+	default:
+		return incoming{err: errorResponse(nil, errInvalidRequest, "Invalid Request: id must be a string, a number or null")}
+	}
+	invalid := func(msg string) incoming {
+		if req.isNotification() {
+			// Invalid notifications are not answered either.
+			return incoming{}
+		}
+		return incoming{err: errorResponse(req.ID, errInvalidRequest, "Invalid Request: "+msg)}
+	}
+	if req.JSONRPC != "2.0" {
+		return invalid(`jsonrpc must be "2.0"`)
+	}
+	if req.isReply() {
+		return incoming{req: &req}
+	}
+	if req.Method == "" {
+		return invalid("method is required")
+	}
+	if p := bytes.TrimSpace(req.Params); len(p) > 0 && p[0] != '{' && p[0] != '[' {
+		return invalid("params must be an object or an array")
+	}
+	return incoming{req: &req}
+}
+
+func errorResponse(id any, code int, message string) *jsonRPCResponse {
+	return &jsonRPCResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+// handleBatch handles the messages of a batch concurrently and returns the
+// responses due, in message order. It returns nil when none is due, e.g. for
+// a batch of notifications.
+func (s *Server) handleBatch(ctx context.Context, sess *session, msgs []incoming, out messageWriter) []*jsonRPCResponse {
+	resps := make([]*jsonRPCResponse, len(msgs))
+	var wg sync.WaitGroup
+	for i, m := range msgs {
+		if m.req == nil {
+			resps[i] = m.err
+			continue
+		}
+		if m.req.Method == "initialize" {
+			resps[i] = errorResponse(m.req.ID, errInvalidRequest, "Invalid Request: initialize must not be part of a batch")
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i] = s.handle(ctx, sess, m.req, out)
+		}()
+	}
+	wg.Wait()
+
+	var due []*jsonRPCResponse
+	for _, r := range resps {
+		if r != nil {
+			due = append(due, r)
+		}
+	}
+	return due
+}
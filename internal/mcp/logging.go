@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// Server logs go to the slog.Logger of WithLogger. Records logged with the
+// context of a request are also sent to its client as notifications/message
+// once the client has chosen a level with logging/setLevel.
+
+// loggerName identifies the server in notifications/message.
+const loggerName = "mcp-po-server"
+
+// logLevels maps the syslog levels of MCP logging to slog levels.
+var logLevels = map[string]slog.Level{
+	"debug":     slog.LevelDebug,
+	"info":      slog.LevelInfo,
+	"notice":    slog.LevelInfo + 2,
+	"warning":   slog.LevelWarn,
+	"error":     slog.LevelError,
+	"critical":  slog.LevelError + 4,
+	"alert":     slog.LevelError + 8,
+	"emergency": slog.LevelError + 12,
+}
+
+// mcpLevel returns the MCP name of a slog level.
+func mcpLevel(l slog.Level) string {
+	name, best := "debug", slog.LevelDebug
+	for n, v := range logLevels {
+		if v <= l && v >= best {
+			name, best = n, v
+		}
+	}
+	return name
+}
+
+type logMessageParams struct {
+	Level  string         `json:"level"`
+	Logger string         `json:"logger,omitempty"`
+	Data   map[string]any `json:"data"`
+}
+
+func (s *Server) handleSetLevel(ctx context.Context, req *jsonRPCRequest) (any, *rpcError) {
+	var params struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, invalidParams("Invalid params: level is required")
+	}
+	level, ok := logLevels[params.Level]
+	if !ok {
+		return nil, invalidParams("Invalid params: unknown level " + params.Level)
+	}
+	sess := sessionFrom(ctx)
+	sess.mu.Lock()
+	sess.logLevel = &level
+	sess.mu.Unlock()
+	return map[string]any{}, nil
+}
+
+// clientHandler passes records to the server's handler and forwards them to
+// the client of the request in the record's context.
+type clientHandler struct {
+	next   slog.Handler
+	attrs  []slog.Attr
+	prefix string // group prefix of attributes added later
+}
+
+func newClientHandler(next slog.Handler) *clientHandler {
+	return &clientHandler{next: next}
+}
+
+func (h *clientHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l) || clientLevel(ctx, l)
+}
+
+func (h *clientHandler) Handle(ctx context.Context, r slog.Record) error {
+	if clientLevel(ctx, r.Level) {
+		data := map[string]any{"message": r.Message}
+		for _, a := range h.attrs {
+			addAttr(data, "", a)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			addAttr(data, h.prefix, a)
+			return true
+		})
+		// Logging must not fail the request; a client gone away just misses
+		// the message.
+		_ = writerFrom(ctx)(jsonRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/message",
+			Params:  logMessageParams{Level: mcpLevel(r.Level), Logger: loggerName, Data: data},
+		})
+	}
+	if h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *clientHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	c.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	c.attrs = append(c.attrs, h.attrs...)
+	for _, a := range attrs {
+		if h.prefix != "" {
+			a.Key = h.prefix + a.Key
+		}
+		c.attrs = append(c.attrs, a)
+	}
+	return &c
+}
+
+func (h *clientHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.next = h.next.WithGroup(name)
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// clientLevel reports whether the client of ctx asked for records of level l.
+func clientLevel(ctx context.Context, l slog.Level) bool {
+	sess, ok := ctx.Value(sessionKey).(*session)
+	if !ok {
+		return false
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.logLevel != nil && l >= *sess.logLevel
+}
+
+// addAttr flattens an attribute into data, joining group keys with dots.
+func addAttr(data map[string]any, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		p := prefix
+		if a.Key != "" {
+			p += a.Key + "."
+		}
+		for _, g := range v.Group() {
+			addAttr(data, p, g)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	key := prefix + a.Key
+	switch v.Kind() {
+	case slog.KindDuration:
+		data[key] = v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			data[key] = err.Error()
+			return
+		}
+		data[key] = v.Any()
+	default:
+		data[key] = v.Any()
+	}
+}
@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a logger.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestClientLogLevel(t *testing.T) {
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := startStdio(t, []Tool{
+		NewTool("ok", "Succeed", func(ctx context.Context, a waitArgs) (*waitResult, error) {
+			return &waitResult{Tag: a.Tag}, nil
+		}),
+		NewTool("fail", "Fail", func(ctx context.Context, a waitArgs) (*waitResult, error) {
+			return nil, errors.New("no luck")
+		}),
+	}, WithLogger(logger))
+
+	// messages returns the notifications/message of a request.
+	messages := func(req rpcMessage) []map[string]any {
+		t.Helper()
+		_, notes := collectCall(c, req)
+		var out []map[string]any
+		for _, note := range notes {
+			if note["method"] != "notifications/message" {
+				t.Fatalf("unexpected notification %v", note)
+			}
+			out = append(out, note["params"].(map[string]any))
+		}
+		return out
+	}
+
+	// Nothing is sent before the client sets a level.
+	if got := messages(callRequest(2, "fail", rpcMessage{"tag": "x"})); len(got) > 0 {
+		t.Fatalf("messages before logging/setLevel: %v", got)
+	}
+
+	if got := messages(rpcMessage{"id": 3, "method": "logging/setLevel", "params": rpcMessage{"level": "warning"}}); len(got) > 0 {
+		t.Fatalf("logging/setLevel sent %v", got)
+	}
+	// The info record of a successful call and the debug one of ping stay
+	// below warning.
+	if got := messages(callRequest(4, "ok", rpcMessage{"tag": "x"})); len(got) > 0 {
+		t.Fatalf("info records sent at level warning: %v", got)
+	}
+	if got := messages(rpcMessage{"id": 5, "method": "ping"}); len(got) > 0 {
+		t.Fatalf("debug records sent at level warning: %v", got)
+	}
+	got := messages(callRequest(6, "fail", rpcMessage{"tag": "x"}))
+	if len(got) != 1 {
+		t.Fatalf("a failed call sent %d messages at level warning, want 1: %v", len(got), got)
+	}
+	data := got[0]["data"].(map[string]any)
+	if got[0]["level"] != "warning" || got[0]["logger"] != loggerName || data["message"] != "tool failed" || data["error"] != "no luck" {
+		t.Fatalf("tool failure message = %v", got[0])
+	}
+
+	messages(rpcMessage{"id": 7, "method": "logging/setLevel", "params": rpcMessage{"level": "info"}})
+	got = messages(callRequest(8, "ok", rpcMessage{"tag": "x"}))
+	if len(got) != 1 || got[0]["level"] != "info" || got[0]["data"].(map[string]any)["message"] != "tool call" {
+		t.Fatalf("successful call at level info sent %v", got)
+	}
+	c.close()
+
+	// Every record still reaches the server's own logger, whatever the client
+	// level.
+	if n := strings.Count(logs.String(), "msg=\"tool call\""); n != 2 {
+		t.Fatalf("server log holds %d tool call records, want 2:\n%s", n, logs.String())
+	}
+}
+
+// In stdio mode stdout carries the protocol: the server logger writes
+// elsewhere and only notifications/message may reach the client.
+func TestStdioLogsStayOffStdout(t *testing.T) {
+	var logs syncBuffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := startStdio(t, nil, WithLogger(logger))
+	c.send(rpcMessage{"id": 2, "method": "ping"})
+	c.send(rpcMessage{"id": 3, "method": "no/such/method"})
+	c.send(callRequest(4, "compile_po", rpcMessage{}))
+	for range 3 {
+		if msg := c.recv(); msg["jsonrpc"] != "2.0" || msg["method"] != nil {
+			t.Fatalf("unexpected message on stdout: %v", msg)
+		}
+	}
+	if rest := c.close(); len(rest) > 0 {
+		t.Fatalf("unexpected messages on stdout: %v", rest)
+	}
+	if !strings.Contains(logs.String(), "request failed") || !strings.Contains(logs.String(), "tool failed") {
+		t.Fatalf("the server log misses the failures:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,283 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
+)
+
+// pathArgument is a file path argument that can replace a content argument.
+type pathArgument struct {
+	path, content string
+	list          bool
+	description   string
+}
+
+var pathArguments = []pathArgument{
+	{path: "po_path", content: "po_content", description: "Path of a .po file inside the workspace, instead of po_content"},
+	{path: "pot_path", content: "pot_content", description: "Path of a .pot file inside the workspace, instead of pot_content"},
+	{path: "po_paths", content: "po_contents", list: true, description: "Paths of .po files inside the workspace, instead of po_contents"},
+	{path: "tmx_path", content: "tmx_content", description: "Path of a TMX file inside the workspace, instead of tmx_content"},
+	{path: "glossary_path", content: "glossary", description: "Path of a TBX or CSV glossary inside the workspace, instead of glossary"},
+}
+
+// outputTools produce a file that output_path can store.
+var outputTools = []string{
+	"compile_po", "filter_po", "concat_po", "common_po", "init_po", "pseudolocalize_po",
+	"pretranslate_po", "translate_po", "update_entries", "export_tmx",
+}
+
+// withPathArguments adds the path variant of every content argument, and
+// with output the arguments writing the result to a file, with a warning in
+// the description. Required content arguments leave the schema's required
+// list, since either form may be given, and are checked by checkArguments.
+func withPathArguments(t *Tool, output bool) {
+	if _, ok := t.InputSchema["properties"].(map[string]any); !ok {
+		return
+	}
+	t.InputSchema = cloneSchema(t.InputSchema)
+	props := t.InputSchema["properties"].(map[string]any)
+	required, _ := t.InputSchema["required"].([]string)
+	required = slices.Clone(required)
+	for _, pa := range pathArguments {
+		if _, ok := props[pa.content]; !ok {
+			continue
+		}
+		if pa.list {
+			props[pa.path] = map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": pa.description}
+		} else {
+			props[pa.path] = map[string]any{"type": "string", "description": pa.description}
+		}
+		if slices.Contains(required, pa.content) {
+			required = slices.DeleteFunc(required, func(r string) bool { return r == pa.content })
+			t.eitherRequired = append(t.eitherRequired, pa)
+		}
+	}
+	if output {
+		t.Description += "; output_path overwrites an existing file (see backup and dry_run)"
+		props["output_path"] = map[string]any{
+			"type":        "string",
+			"description": "Write the result to this file or directory inside the workspace instead of returning it; {domain} and {locale} are filled from the catalog, .mo paths receive the compiled catalog",
+		}
+		props["domain"] = map[string]any{
+			"type":        "string",
+			"description": "Text domain used to name the output (default X-Domain header); a directory output_path gets {domain}-{locale}",
+		}
+		props["backup"] = map[string]any{
+			"type":        "boolean",
+			"default":     false,
+			"description": "Keep the file replaced by output_path as <name>.bak",
+		}
+		props["dry_run"] = map[string]any{
+			"type":        "boolean",
+			"default":     false,
+			"description": "Report whether output_path would be created or replaced, with a diff for text files, without writing anything",
+		}
+	}
+	if len(required) > 0 {
+		t.InputSchema["required"] = required
+	} else {
+		delete(t.InputSchema, "required")
+	}
+}
+
+// loadPathArguments replaces path arguments by the content of their files.
+func (s *Server) loadPathArguments(args map[string]any) error {
+	for _, pa := range pathArguments {
+		if _, ok := args[pa.path]; !ok {
+			continue
+		}
+		if _, ok := args[pa.content]; ok {
+			return fmt.Errorf("pass %s or %s, not both", pa.content, pa.path)
+		}
+		if !pa.list {
+			data, err := s.readText(stringArg(args, pa.path))
+			if err != nil {
+				return err
+			}
+			args[pa.content] = data
+			continue
+		}
+		var contents []any
+		for _, p := range stringSliceArg(args, pa.path) {
+			data, err := s.readText(p)
+			if err != nil {
+				return err
+			}
+			contents = append(contents, data)
+		}
+		args[pa.content] = contents
+	}
+	return nil
+}
+
+// readText reads a workspace file that must hold text, such as a catalog
+// passed by path; an .mo given by mistake is rejected.
+func (s *Server) readText(path string) (string, error) {
+	data, err := s.workspace.Read(path)
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("%s is a binary file", path)
+	}
+	return string(data), nil
+}
+
+// writeOutput stores the file a tool produced at outputPath and returns the
+// result without the stored content, so it does not fill the context.
+//
+// outputPath may name a directory (the WordPress file name is then derived
+// from the catalog) and may use the {domain} and {locale} placeholders.
+func (s *Server) writeOutput(name string, args map[string]any, result any, outputPath string) (any, error) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("tool result cannot be written to a file")
+	}
+
+	// The catalog the output is named after: the produced one when the tool
+	// returns a catalog (init_po sets Language), otherwise the input.
+	naming, ok := fields["po_content"].(string)
+	if !ok {
+		naming = stringArg(args, "po_content")
+	}
+	domain := stringArg(args, "domain")
+	if strings.HasSuffix(outputPath, "/") || s.workspace.IsDir(outputPath) {
+		file, err := po.DefaultOutputName(naming, domain, outputExt(name))
+		if err != nil {
+			return nil, err
+		}
+		outputPath = filepath.Join(outputPath, file)
+	} else if outputPath, err = po.ExpandOutputPath(outputPath, naming, domain); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var stored string
+	if strings.EqualFold(filepath.Ext(outputPath), ".mo") {
+		for _, key := range []string{"mo_base64", "Base64"} {
+			if b64, ok := fields[key].(string); ok && b64 != "" {
+				if data, err = base64.StdEncoding.DecodeString(b64); err != nil {
+					return nil, err
+				}
+				stored = key
+				break
+			}
+		}
+	} else {
+		for _, key := range []string{"po_content", "tmx_content"} {
+			if content, ok := fields[key].(string); ok {
+				data = []byte(content)
+				stored = key
+				break
+			}
+		}
+	}
+	if stored == "" {
+		return nil, fmt.Errorf("%s produces no file for %s", name, filepath.Base(outputPath))
+	}
+
+	var written *workspace.Written
+	if boolArg(args, "dry_run") {
+		written, err = s.previewOutput(fields, outputPath, data, stored, boolArg(args, "backup"))
+	} else {
+		written, err = s.workspace.Write(outputPath, data, workspace.WriteOptions{Backup: boolArg(args, "backup")})
+	}
+	if err != nil {
+		return nil, err
+	}
+	delete(fields, stored)
+	if _, ok := fields["Path"]; ok {
+		fields["Path"] = written.Path
+	} else {
+		fields["output_path"] = written.Path
+	}
+	if written.Backup != "" {
+		fields["backup_path"] = written.Backup
+	}
+	return fields, nil
+}
+
+// previewOutput describes in fields what writing data to outputPath would
+// change, without writing it: whether the file would be created, replaced or
+// left unchanged and, for text files, the diff from the current content.
+func (s *Server) previewOutput(fields map[string]any, outputPath string, data []byte, stored string, backup bool) (*workspace.Written, error) {
+	real, err := s.workspace.Resolve(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	written := &workspace.Written{Path: real}
+	change := "create"
+	current, err := s.workspace.Read(real)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case bytes.Equal(current, data):
+		change = "unchanged"
+	default:
+		change = "replace"
+		if backup {
+			written.Backup = real + ".bak"
+		}
+	}
+	fields["dry_run"] = true
+	fields["change"] = change
+	if change != "unchanged" && stored != "mo_base64" && stored != "Base64" {
+		name := filepath.Base(real)
+		fields["output_diff"] = po.UnifiedDiff("a/"+name, "b/"+name, string(current), string(data))
+	}
+	return written, nil
+}
+
+// outputExt is the extension of the file a tool writes to a directory.
+func outputExt(name string) string {
+	switch name {
+	case "compile_po":
+		return ".mo"
+	case "export_tmx":
+		return ".tmx"
+	default:
+		return ".po"
+	}
+}
+
+// refreshRoots asks the client for its roots and confines the workspace to
+// them.
+func (s *Server) refreshRoots(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var res struct {
+		Roots []struct {
+			URI  string `json:"uri"`
+			Name string `json:"name"`
+		} `json:"roots"`
+	}
+	if err := s.call(ctx, "roots/list", nil, &res); err != nil {
+		s.logger.WarnContext(ctx, "roots/list failed", "error", err)
+		return
+	}
+	uris := make([]string, 0, len(res.Roots))
+	for _, r := range res.Roots {
+		uris = append(uris, r.URI)
+	}
+	if err := s.workspace.SetClientRoots(uris); err != nil {
+		s.logger.WarnContext(ctx, "ignoring client roots", "roots", uris, "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
+)
+
+func workspaceServer(t *testing.T, opts ...Option) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ws, err := workspace.New(dir)
+	if err != nil {
+		t.Fatalf("workspace.New: %v", err)
+	}
+	catalog, err := os.ReadFile("../../test/incomplete-de_DE.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "de_DE.po"), catalog, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(append([]Option{WithWorkspace(ws)}, opts...)...), dir
+}
+
+func TestDryRunOutput(t *testing.T) {
+	s, dir := workspaceServer(t)
+	out := filepath.Join(dir, "fuzzy.po")
+	args := func() map[string]any {
+		return map[string]any{"po_path": "de_DE.po", "states": "fuzzy", "output_path": "fuzzy.po", "backup": true, "dry_run": true}
+	}
+
+	res := call(t, s, "filter_po", args())
+	if res.IsError {
+		t.Fatalf("filter_po: %s", res.Content[0].Text)
+	}
+	got := res.StructuredContent
+	if got["dry_run"] != true || got["change"] != "create" || got["po_content"] != nil || got["backup_path"] != nil {
+		t.Fatalf("dry run of a new file = %v", got)
+	}
+	if !strings.Contains(got["output_diff"].(string), "+++ b/fuzzy.po") {
+		t.Fatalf("output_diff = %q, want a diff against an empty file", got["output_diff"])
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("dry run created %s", out)
+	}
+
+	if err := os.WriteFile(out, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got = call(t, s, "filter_po", args()).StructuredContent
+	if got["change"] != "replace" || got["backup_path"] != out+".bak" || !strings.Contains(got["output_diff"].(string), "-old") {
+		t.Fatalf("dry run over an existing file = %v", got)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "old\n" {
+		t.Fatalf("dry run replaced %s", out)
+	}
+	if _, err := os.Stat(out + ".bak"); !os.IsNotExist(err) {
+		t.Fatalf("dry run created a backup")
+	}
+
+	written := args()
+	delete(written, "dry_run")
+	if res := call(t, s, "filter_po", written); res.IsError {
+		t.Fatalf("filter_po: %s", res.Content[0].Text)
+	}
+	got = call(t, s, "filter_po", args()).StructuredContent
+	if got["change"] != "unchanged" || got["output_diff"] != nil {
+		t.Fatalf("dry run over an identical file = %v", got)
+	}
+}
+
+func TestDryRunOverMO(t *testing.T) {
+	s, dir := workspaceServer(t)
+	args := map[string]any{"po_path": "de_DE.po", "output_path": "de_DE.mo"}
+	if res := call(t, s, "compile_po", args); res.IsError {
+		t.Fatalf("compile_po: %s", res.Content[0].Text)
+	}
+	mo, _ := os.ReadFile(filepath.Join(dir, "de_DE.mo"))
+
+	args["dry_run"] = true
+	res := call(t, s, "compile_po", args)
+	if res.IsError || res.StructuredContent["change"] != "unchanged" {
+		t.Fatalf("dry run over the same .mo = %+v", res)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "de_DE.mo"), []byte{0xde, 0x12, 0x04, 0x95, 0, 0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := call(t, s, "compile_po", args).StructuredContent
+	if got["change"] != "replace" || got["output_diff"] != nil {
+		t.Fatalf("dry run over another .mo = %v", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "de_DE.mo")); len(data) != 6 || len(mo) == 0 {
+		t.Fatalf("dry run replaced de_DE.mo")
+	}
+}
+
+func TestCompileDirDryRun(t *testing.T) {
+	memoryFile := filepath.Join(t.TempDir(), "memory.json")
+	memory, err := po.OpenMemory(memoryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, dir := workspaceServer(t, WithService(po.NewService(po.WithMemory(memory))))
+	got := call(t, s, "compile_dir", map[string]any{"path": ".", "dry_run": true}).StructuredContent
+	files := got["files"].([]any)
+	if got["dry_run"] != true || got["compiled"] != float64(1) || len(files) != 1 {
+		t.Fatalf("compile_dir dry run = %v", got)
+	}
+	file := files[0].(map[string]any)
+	if file["status"] != "would_compile" || file["outputs"].([]any)[0] != "de_DE.mo" {
+		t.Fatalf("compile_dir dry run file = %v", file)
+	}
+	for _, name := range []string{"de_DE.mo", buildCacheFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("dry run wrote %s", name)
+		}
+	}
+
+	res := call(t, s, "compile_po", map[string]any{"po_path": "de_DE.po", "output_path": "de_DE.mo", "dry_run": true})
+	if res.IsError {
+		t.Fatalf("compile_po: %s", res.Content[0].Text)
+	}
+	if _, err := os.Stat(memoryFile); memory.Len() != 0 || !os.IsNotExist(err) {
+		t.Fatalf("dry runs fed the translation memory: %d units", memory.Len())
+	}
+}
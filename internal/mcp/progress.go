@@ -0,0 +1,44 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+)
+
+// progressInterval is the minimum delay between two progress notifications
+// of a request; the final one is always sent.
+const progressInterval = 100 * time.Millisecond
+
+type progressParams struct {
+	ProgressToken any    `json:"progressToken"`
+	Progress      int    `json:"progress"`
+	Total         int    `json:"total,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// progressReporter returns a po.ProgressFunc sending notifications/progress
+// for the token the client passed in _meta.progressToken. Progress must
+// increase, so reports that do not advance it, as from parallel workers
+// finishing out of order, are dropped.
+func progressReporter(ctx context.Context, token any) po.ProgressFunc {
+	out := writerFrom(ctx)
+	var mu sync.Mutex
+	last := 0
+	var sent time.Time
+	return func(done, total int, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done <= last || (done != total && time.Since(sent) < progressInterval) {
+			return
+		}
+		last, sent = done, time.Now()
+		_ = out(jsonRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params:  progressParams{ProgressToken: token, Progress: done, Total: total, Message: message},
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
+)
+
+// collectCall sends a tools/call and returns its response with the
+// notifications received before it.
+func collectCall(c *stdioClient, req rpcMessage) (rpcMessage, []rpcMessage) {
+	c.t.Helper()
+	c.send(req)
+	var notes []rpcMessage
+	for {
+		msg := c.recv()
+		if _, ok := msg["id"]; ok {
+			return msg, notes
+		}
+		notes = append(notes, msg)
+	}
+}
+
+func TestCompileDirProgress(t *testing.T) {
+	dir := t.TempDir()
+	catalog, err := os.ReadFile("../../test/incomplete-de_DE.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locales := []string{"de_DE", "fr_FR", "es_ES", "it_IT", "nl_NL"}
+	for _, locale := range locales {
+		if err := os.WriteFile(filepath.Join(dir, locale+".po"), catalog, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws, err := workspace.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := startStdio(t, nil, WithWorkspace(ws))
+
+	req := callRequest(2, "compile_dir", rpcMessage{"path": "."})
+	req["params"].(rpcMessage)["_meta"] = rpcMessage{"progressToken": "build-1"}
+	resp, notes := collectCall(c, req)
+	if resp["result"] == nil {
+		t.Fatalf("compile_dir: %v", resp)
+	}
+	if len(notes) == 0 {
+		t.Fatalf("no notifications/progress for a call with a progress token")
+	}
+	last := 0.0
+	for _, note := range notes {
+		params, _ := note["params"].(map[string]any)
+		if note["method"] != "notifications/progress" || params["progressToken"] != "build-1" {
+			t.Fatalf("unexpected notification %v", note)
+		}
+		progress, _ := params["progress"].(float64)
+		if progress <= last {
+			t.Fatalf("progress went from %v to %v", last, progress)
+		}
+		last = progress
+		if params["total"] != float64(len(locales)) {
+			t.Fatalf("total = %v, want %d", params["total"], len(locales))
+		}
+	}
+	if last != float64(len(locales)) {
+		t.Fatalf("final progress = %v, want %d", last, len(locales))
+	}
+
+	resp, notes = collectCall(c, callRequest(3, "compile_dir", rpcMessage{"path": ".", "force": true}))
+	if resp["result"] == nil {
+		t.Fatalf("compile_dir: %v", resp)
+	}
+	if len(notes) > 0 {
+		t.Fatalf("a call without a progress token got %v", notes)
+	}
+	c.close()
+}
@@ -0,0 +1,363 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+)
+
+// Prompts are vetted instructions for the usual catalog chores, filled from
+// the catalog they apply to: its plural rule, glossary terms and the entries
+// with their comments. Every session starts from the same wording instead of
+// an ad-hoc user prompt.
+
+type promptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type promptDefinition struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []promptArgument `json:"arguments,omitempty"`
+}
+
+type promptMessage struct {
+	Role    string       `json:"role"`
+	Content contentBlock `json:"content"`
+}
+
+type getPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []promptMessage `json:"messages"`
+}
+
+// promptEntryTokens bounds the entries a prompt embeds; the rest are paged
+// with list_entries.
+const promptEntryTokens = 8000
+
+var (
+	catalogArgument = promptArgument{
+		Name:        "catalog",
+		Description: "Catalog resource URI (po://{project}/{locale}) or path of a .po file inside the workspace",
+		Required:    true,
+	}
+	projectArgument = promptArgument{
+		Name:        "project",
+		Description: "Glossary project (default: the catalog text domain)",
+	}
+)
+
+var prompts = []promptDefinition{
+	{
+		Name:        "translate_catalog",
+		Title:       "Translate untranslated entries",
+		Description: "Translate the untranslated entries of a catalog, following its plural rule and glossary",
+		Arguments: []promptArgument{
+			catalogArgument,
+			{Name: "locale", Description: "Target locale (default: the catalog Language header)"},
+			projectArgument,
+		},
+	},
+	{
+		Name:        "review_fuzzy",
+		Title:       "Review fuzzy entries",
+		Description: "Review the fuzzy entries of a catalog against their source and confirm or fix them",
+		Arguments:   []promptArgument{catalogArgument, projectArgument},
+	},
+	{
+		Name:        "explain_validation",
+		Title:       "Explain validation errors",
+		Description: "Explain the validation and glossary warnings of a catalog and propose fixes",
+		Arguments:   []promptArgument{catalogArgument, projectArgument},
+	},
+}
+
+func (s *Server) handlePromptsGet(ctx context.Context, req *jsonRPCRequest) (any, *rpcError) {
+	var params struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, invalidParams("Invalid params")
+	}
+	res, err := s.getPrompt(ctx, params.Name, params.Arguments)
+	if err != nil {
+		return nil, invalidParams(err.Error())
+	}
+	return res, nil
+}
+
+func (s *Server) getPrompt(ctx context.Context, name string, args map[string]string) (*getPromptResult, error) {
+	var def *promptDefinition
+	for i := range prompts {
+		if prompts[i].Name == name {
+			def = &prompts[i]
+		}
+	}
+	if def == nil {
+		return nil, fmt.Errorf("unknown prompt: %s", name)
+	}
+	for _, a := range def.Arguments {
+		if a.Required && args[a.Name] == "" {
+			return nil, fmt.Errorf("prompt %s requires the %s argument", name, a.Name)
+		}
+	}
+
+	c, err := s.promptCatalog(args["catalog"])
+	if err != nil {
+		return nil, err
+	}
+	glossary, err := s.promptGlossary(args["project"], c.project)
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	switch name {
+	case "translate_catalog":
+		text, err = s.translatePrompt(ctx, c, args["locale"], glossary)
+	case "review_fuzzy":
+		text, err = s.reviewPrompt(ctx, c, glossary)
+	case "explain_validation":
+		text, err = s.validationPrompt(ctx, c, glossary)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &getPromptResult{
+		Description: fmt.Sprintf("%s: %s", def.Title, c.uri()),
+		Messages:    []promptMessage{{Role: "user", Content: contentBlock{Type: "text", Text: text}}},
+	}, nil
+}
+
+// promptCatalog loads the catalog named by a po:// URI or a workspace path.
+func (s *Server) promptCatalog(ref string) (*catalogFile, error) {
+	if strings.HasPrefix(ref, resourceScheme) {
+		var c *catalogFile
+		var err error
+		if parts := resourcePath(ref); len(parts) == 2 {
+			c, err = s.catalog(parts[0], parts[1])
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("catalog not found: %s", ref)
+		}
+		return c, nil
+	}
+
+	path, err := s.workspace.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadCatalog(filepath.Dir(path), path)
+}
+
+// promptGlossary loads the glossary of the project argument, or of the
+// catalog domain when one exists for it. A domain glossary that exists but
+// cannot be read or parsed is an error.
+func (s *Server) promptGlossary(project, domain string) (*po.Glossary, error) {
+	if project != "" {
+		return s.po.ProjectGlossary(project)
+	}
+	if domain == "" {
+		return nil, nil
+	}
+	g, err := s.po.ProjectGlossary(domain)
+	if errors.Is(err, po.ErrGlossaryNotFound) {
+		return nil, nil
+	}
+	return g, err
+}
+
+func (s *Server) translatePrompt(ctx context.Context, c *catalogFile, locale string, glossary *po.Glossary) (string, error) {
+	cat, err := po.ParseCatalog(c.content)
+	if err != nil {
+		return "", err
+	}
+	if locale == "" {
+		locale = c.locale
+	}
+	locale = po.NormalizeLocale(locale)
+	pluralForms := cat.HeaderField("Plural-Forms")
+	if rule, ok := po.PluralFormsFor(locale); ok && (pluralForms == "" || locale != c.locale) {
+		pluralForms = rule
+	}
+	list, err := s.po.ListEntries(ctx, c.content, po.EntryFilter{States: []string{"untranslated"}}, po.ListOptions{Limit: 200, MaxTokens: promptEntryTokens})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Translate the untranslated entries of the gettext catalog %s (%s) into %s.\n\n", c.rel, c.uri(), languageLabel(locale))
+	writeEntryCount(&b, list, "untranslated")
+	b.WriteString(`Rules:
+- Keep placeholders (%s, %1$d, {name}), HTML tags and entities exactly as in the source; reorder them only when the grammar requires.
+- msgctxt disambiguates the string; comments, extracted_comments and references are notes for translators and are never translated.
+- Match the tone and length of a user interface, and keep product and brand names as they are.
+`)
+	if pluralForms != "" {
+		fmt.Fprintf(&b, "- The plural rule is %q: entries with msgid_plural need %d msgstr forms, in the order of that rule.\n", pluralForms, po.NPlurals(pluralForms))
+	}
+	writeGlossary(ctx, &b, s.po, glossary, list.Entries, locale)
+	fmt.Fprintf(&b, `
+Save the translations with update_entries: po_path %q, base_hash %q, and one edit per entry with its id and msgstr (msgstr_plural for plural entries). Add the fuzzy flag only to translations you are unsure of, with a translator comment saying why.
+`, c.path, list.Hash)
+	s.writeReadOnlyNote(&b)
+	if err := writeEntries(&b, list.Entries); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (s *Server) reviewPrompt(ctx context.Context, c *catalogFile, glossary *po.Glossary) (string, error) {
+	list, err := s.po.ListEntries(ctx, c.content, po.EntryFilter{States: []string{"fuzzy"}}, po.ListOptions{Limit: 200, MaxTokens: promptEntryTokens})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review the fuzzy entries of the gettext catalog %s (%s), translated into %s.\n\n", c.rel, c.uri(), languageLabel(c.locale))
+	writeEntryCount(&b, list, "fuzzy")
+	fmt.Fprintf(&b, `For each entry:
+- Compare msgstr with msgid. When "previous" is present, it holds the source the translation was made for: check what changed in the source and update the translation to match.
+- Entries with a %q extracted comment come from machine translation and need a full review.
+- Check placeholders, HTML tags, plural forms and the glossary below.
+- If the translation is right, or once you fixed it, remove the fuzzy flag. If you are still unsure, keep the flag and add a translator comment saying what needs checking.
+`, po.MachineTranslatedComment)
+	writeGlossary(ctx, &b, s.po, glossary, list.Entries, c.locale)
+	fmt.Fprintf(&b, `
+Apply the review with update_entries: po_path %q, base_hash %q, and one edit per entry with its id, the corrected msgstr (or msgstr_plural) and remove_flags ["fuzzy"] for confirmed entries. Summarize what you changed and what is left fuzzy.
+`, c.path, list.Hash)
+	s.writeReadOnlyNote(&b)
+	if err := writeEntries(&b, list.Entries); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (s *Server) validationPrompt(ctx context.Context, c *catalogFile, glossary *po.Glossary) (string, error) {
+	warnings, _, err := s.po.Validate(ctx, c.content)
+	if err != nil {
+		return "", err
+	}
+	cat, err := po.ParseCatalog(c.content)
+	if err != nil {
+		return "", err
+	}
+	// Validate's summary counts fuzzy entries as translated.
+	states := make(map[string]int)
+	messages := 0
+	for _, e := range cat.Messages() {
+		if !e.Obsolete {
+			states[e.State()]++
+			messages++
+		}
+	}
+	if glossary != nil {
+		glossaryWarnings, err := s.po.CheckGlossary(ctx, c.content, glossary)
+		if err != nil {
+			return "", err
+		}
+		warnings = append(warnings, glossaryWarnings...)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Explain the validation results of the gettext catalog %s (%s) to the maintainer of a WordPress project.\n\n", c.rel, c.uri())
+	fmt.Fprintf(&b, "The catalog is in %s and has %d messages: %d translated, %d fuzzy, %d untranslated.\n\n",
+		languageLabel(c.locale), messages, states[po.StateTranslated], states[po.StateFuzzy], states[po.StateUntranslated])
+	if len(warnings) == 0 {
+		b.WriteString("Validation reported no warnings. Say so, and point out anything in the counts above that still needs attention before release.\n")
+		return b.String(), nil
+	}
+	b.WriteString(`For each kind of warning below:
+- Explain in plain words what is wrong and what users of the site would see (missing headers break plural handling or the charset, untranslated and fuzzy entries show in the source language, glossary warnings mean inconsistent terminology).
+- Propose the fix, grouping warnings that share a cause instead of repeating the same advice.
+- Say which fixes can be applied with update_entries (po_path ` + fmt.Sprintf("%q", c.path) + `) and which need the project itself, such as regenerating the POT.
+`)
+	s.writeReadOnlyNote(&b)
+	b.WriteString("\nWarnings:\n")
+	for _, w := range warnings {
+		fmt.Fprintf(&b, "- %s\n", w)
+	}
+	return b.String(), nil
+}
+
+// writeReadOnlyNote tells the model that update_entries cannot save the
+// catalog on a read-only server, so the edits reach the user as a diff.
+func (s *Server) writeReadOnlyNote(b *strings.Builder) {
+	if s.readOnly {
+		b.WriteString("This server is read-only: update_entries does not save the catalog but returns the edited file with its diff. Do not retry the save; give the diff to the user to apply.\n")
+	}
+}
+
+// languageLabel names a locale for a prompt, e.g. "Spanish (Spain) (es_ES)".
+func languageLabel(locale string) string {
+	if name := po.LanguageName(locale); name != "" {
+		return name + " (" + locale + ")"
+	}
+	return locale
+}
+
+func writeEntryCount(b *strings.Builder, list *po.ListResult, state string) {
+	switch {
+	case list.Matched == 0:
+		fmt.Fprintf(b, "The catalog has no %s entries; report that there is nothing to do.\n\n", state)
+	case list.NextCursor != "":
+		fmt.Fprintf(b, "%d of %d entries are %s; the first %d are listed below. Fetch the rest with list_entries (states [%q], cursor %q) once these are done.\n\n",
+			list.Matched, list.Total, state, len(list.Entries), state, list.NextCursor)
+	default:
+		fmt.Fprintf(b, "%d of %d entries are %s; all of them are listed below.\n\n", list.Matched, list.Total, state)
+	}
+}
+
+// writeGlossary lists the glossary terms used by the entries.
+func writeGlossary(ctx context.Context, b *strings.Builder, svc *po.Service, glossary *po.Glossary, entries []po.ListedEntry, locale string) {
+	if glossary == nil || len(entries) == 0 {
+		return
+	}
+	var text strings.Builder
+	for _, e := range entries {
+		text.WriteString(e.Msgid + "\n" + e.MsgidPlural + "\n")
+	}
+	found := svc.GlossaryLookup(ctx, glossary, text.String(), locale)
+	if len(found.Terms) == 0 {
+		return
+	}
+	b.WriteString("- Use the project glossary:\n")
+	for _, t := range found.Terms {
+		switch {
+		case t.DoNotTranslate:
+			fmt.Fprintf(b, "  - %q: never translate", t.Source)
+		case len(t.Targets) > 0:
+			fmt.Fprintf(b, "  - %q: %s", t.Source, strings.Join(t.Targets, " or "))
+		default:
+			continue
+		}
+		if t.Note != "" {
+			fmt.Fprintf(b, " (%s)", t.Note)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func writeEntries(b *strings.Builder, entries []po.ListedEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "\nEntries:\n```json\n%s\n```\n", data)
+	return nil
+}
@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
+)
+
+const promptPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Language: es_ES\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Share on Pinterest"
+msgstr ""
+
+#, fuzzy
+msgid "Request a quote"
+msgstr "Pide un precio"
+
+msgid "Ask for a quote"
+msgstr "Pide un precio"
+
+msgid "%d board"
+msgid_plural "%d boards"
+msgstr[0] ""
+msgstr[1] ""
+`
+
+const promptGlossaryCSV = `source,es_ES,dnt,note
+Pinterest,,yes,Brand name
+quote,presupuesto,,Price estimate
+`
+
+// promptServer serves a workspace holding languages/scp-pinterest-es_ES.po,
+// with the scp-pinterest glossary in a glossary dir.
+func promptServer(t *testing.T, opts ...Option) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	glossaries := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "languages"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "languages", "scp-pinterest-es_ES.po")
+	if err := os.WriteFile(file, []byte(promptPO), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(glossaries, "scp-pinterest.csv"), []byte(promptGlossaryCSV), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := workspace.New(dir)
+	if err != nil {
+		t.Fatalf("workspace.New: %v", err)
+	}
+	opts = append([]Option{WithWorkspace(ws), WithService(po.NewService(po.WithGlossaryDir(glossaries)))}, opts...)
+	return NewServer(opts...), file
+}
+
+func getPrompt(s *Server, name string, args map[string]string) (string, *rpcError) {
+	params, _ := json.Marshal(map[string]any{"name": name, "arguments": args})
+	res, rpcErr := s.handlePromptsGet(context.Background(), &jsonRPCRequest{ID: 1, Params: params})
+	if rpcErr != nil {
+		return "", rpcErr
+	}
+	msgs := res.(*getPromptResult).Messages
+	if len(msgs) != 1 || msgs[0].Role != "user" {
+		return "", &rpcError{Message: "unexpected messages"}
+	}
+	return msgs[0].Content.Text, nil
+}
+
+func TestPromptsGet(t *testing.T) {
+	s, file := promptServer(t)
+	const uri = "po://scp-pinterest/es_ES"
+	handle := `po_path "` + strings.ReplaceAll(file, `\`, `\\`) + `", base_hash "` + po.ContentHash(promptPO) + `"`
+
+	for _, catalog := range []string{uri, "languages/scp-pinterest-es_ES.po"} {
+		text, rpcErr := getPrompt(s, "translate_catalog", map[string]string{"catalog": catalog})
+		if rpcErr != nil {
+			t.Fatalf("translate_catalog %s: %v", catalog, rpcErr)
+		}
+		for _, want := range []string{
+			"into Spanish (Spain) (es_ES)",
+			"2 of 4 entries are untranslated; all of them are listed below.",
+			`- The plural rule is "nplurals=2; plural=(n != 1);": entries with msgid_plural need 2 msgstr forms`,
+			`  - "Pinterest": never translate (Brand name)`,
+			handle,
+			`"msgid": "%d board"`,
+		} {
+			if !strings.Contains(text, want) {
+				t.Fatalf("translate_catalog %s lacks %q:\n%s", catalog, want, text)
+			}
+		}
+		if strings.Contains(text, "Request a quote") {
+			t.Fatalf("translate_catalog lists a fuzzy entry:\n%s", text)
+		}
+	}
+
+	text, rpcErr := getPrompt(s, "translate_catalog", map[string]string{"catalog": uri, "locale": "ru"})
+	if rpcErr != nil || !strings.Contains(text, "need 3 msgstr forms") {
+		t.Fatalf("translate_catalog into ru must use the Russian plural rule: %v\n%s", rpcErr, text)
+	}
+
+	text, rpcErr = getPrompt(s, "review_fuzzy", map[string]string{"catalog": uri})
+	if rpcErr != nil {
+		t.Fatalf("review_fuzzy: %v", rpcErr)
+	}
+	for _, want := range []string{
+		"1 of 4 entries are fuzzy",
+		`  - "quote": presupuesto (Price estimate)`,
+		handle,
+		`"msgid": "Request a quote"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("review_fuzzy lacks %q:\n%s", want, text)
+		}
+	}
+
+	text, rpcErr = getPrompt(s, "explain_validation", map[string]string{"catalog": uri})
+	if rpcErr != nil {
+		t.Fatalf("explain_validation: %v", rpcErr)
+	}
+	for _, want := range []string{
+		"has 4 messages: 1 translated, 1 fuzzy, 2 untranslated",
+		`update_entries (po_path "` + strings.ReplaceAll(file, `\`, `\\`) + `")`,
+		"Ask for a quote",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("explain_validation lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestPromptsReadOnly(t *testing.T) {
+	const note = "This server is read-only: update_entries does not save the catalog"
+	for _, readOnly := range []bool{false, true} {
+		var opts []Option
+		if readOnly {
+			opts = append(opts, WithReadOnly())
+		}
+		s, _ := promptServer(t, opts...)
+		if _, ok := s.tool("update_entries"); !ok {
+			t.Fatalf("update_entries is not served (read-only %v)", readOnly)
+		}
+		for _, p := range prompts {
+			text, rpcErr := getPrompt(s, p.Name, map[string]string{"catalog": "po://scp-pinterest/es_ES"})
+			if rpcErr != nil {
+				t.Fatalf("%s: %v", p.Name, rpcErr)
+			}
+			if strings.Contains(text, note) != readOnly {
+				t.Fatalf("%s on a server with read-only %v:\n%s", p.Name, readOnly, text)
+			}
+		}
+	}
+}
+
+func TestPromptsGetErrors(t *testing.T) {
+	s, _ := promptServer(t)
+	for _, tc := range []struct {
+		name string
+		args map[string]string
+		want string
+	}{
+		{"translate_catalog", nil, "requires the catalog argument"},
+		{"review_fuzzy", map[string]string{"catalog": "po://scp-pinterest/fr_FR"}, "catalog not found: po://scp-pinterest/fr_FR"},
+		{"review_fuzzy", map[string]string{"catalog": "po://scp-pinterest"}, "catalog not found"},
+		{"explain_validation", map[string]string{"catalog": "../outside.po"}, "outside"},
+		{"explain_validation", map[string]string{"catalog": "po://scp-pinterest/es_ES", "project": "missing"}, "glossary not found"},
+		{"unknown", map[string]string{"catalog": "po://scp-pinterest/es_ES"}, "unknown prompt"},
+	} {
+		_, rpcErr := getPrompt(s, tc.name, tc.args)
+		if rpcErr == nil || rpcErr.Code != errInvalidParams || !strings.Contains(rpcErr.Message, tc.want) {
+			t.Fatalf("%s %v = %v, want an invalid params error with %q", tc.name, tc.args, rpcErr, tc.want)
+		}
+	}
+}
+
+// Without a project argument, a missing domain glossary is fine but a broken
+// one is reported.
+func TestPromptGlossaryFromDomain(t *testing.T) {
+	s, _ := promptServer(t)
+	if g, err := s.promptGlossary("", "other"); g != nil || err != nil {
+		t.Fatalf("promptGlossary without a glossary = %v, %v", g, err)
+	}
+	if g, err := s.promptGlossary("", "scp-pinterest"); g == nil || err != nil {
+		t.Fatalf("promptGlossary of the catalog domain = %v, %v", g, err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "scp-pinterest.tbx"), []byte("<martif><text>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.po = po.NewService(po.WithGlossaryDir(dir))
+	if _, err := s.promptGlossary("", "scp-pinterest"); err == nil {
+		t.Fatalf("promptGlossary must report a malformed domain glossary")
+	}
+	if _, rpcErr := getPrompt(s, "explain_validation", map[string]string{"catalog": "po://scp-pinterest/es_ES"}); rpcErr == nil {
+		t.Fatalf("prompts/get must fail on a malformed domain glossary")
+	}
+}
@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// Tool is a tool served by a Server. tools/list, tools/call and the
+// manifest are all generated from the registered tools.
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema describes the arguments. They are checked against it, and
+	// receive its defaults, before Handler runs.
+	InputSchema map[string]any
+	// OutputSchema describes the result of tools returning a JSON object.
+	// Clients of revisions with structured output receive it, and the result
+	// as structuredContent.
+	OutputSchema map[string]any
+	Annotations  *ToolAnnotations
+	// Handler runs the tool. Its result is sent to the client as JSON.
+	Handler func(ctx context.Context, args map[string]any) (any, error)
+
+	// eitherRequired are the required content arguments that may be given
+	// as content or by path; one of the two must be.
+	eitherRequired []pathArgument
+}
+
+// ToolAnnotations are hints about the behavior of a tool, as defined by MCP
+// from revision 2025-03-26. Clients must not rely on them for security.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
+}
+
+// annotationsVersion is the first revision with tool annotations.
+const annotationsVersion = "2025-03-26"
+
+// WithAnnotations returns a copy of t carrying the annotations a.
+func (t Tool) WithAnnotations(a ToolAnnotations) Tool {
+	t.Annotations = &a
+	return t
+}
+
+// Hint returns a pointer to b, for the fields of ToolAnnotations.
+func Hint(b bool) *bool {
+	return &b
+}
+
+// readOnly reports whether the annotations of t say it does not modify its
+// environment; as in MCP, a tool without annotations is assumed to.
+func (t *Tool) readOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint != nil && *t.Annotations.ReadOnlyHint
+}
+
+// NewTool defines a tool whose arguments decode into the struct A. The input
+// schema is derived from A (see argumentSchema) and, when R is a struct or a
+// pointer to one, the output schema from R.
+func NewTool[A, R any](name, description string, handler func(ctx context.Context, args A) (R, error)) Tool {
+	tool := Tool{
+		Name:        name,
+		Description: description,
+		InputSchema: argumentSchema(reflect.TypeFor[A]()),
+		Handler: func(ctx context.Context, args map[string]any) (any, error) {
+			var a A
+			raw, err := json.Marshal(args)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(raw, &a); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			return handler(ctx, a)
+		},
+	}
+	rt := reflect.TypeFor[R]()
+	if rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() == reflect.Struct {
+		tool.OutputSchema = schemaFor(rt)
+	}
+	return tool
+}
+
+// RegisterTool adds a tool to the server; it must be called before Serve.
+// Like the built-in tools, a tool with a po_content, pot_content,
+// po_contents, tmx_content or glossary argument also accepts the matching
+// path argument, read from the workspace before Handler runs.
+//
+// A read-only server does not serve tools whose annotations lack
+// ReadOnlyHint: they are left out of tools/list and calls to them fail.
+func (s *Server) RegisterTool(t Tool) error {
+	if t.Name == "" || t.Handler == nil {
+		return errors.New("a tool needs a name and a handler")
+	}
+	if _, ok := s.tool(t.Name); ok || slices.Contains(s.disabled, t.Name) {
+		return fmt.Errorf("tool %s is already registered", t.Name)
+	}
+	if t.InputSchema == nil {
+		t.InputSchema = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	writesOutput := slices.Contains(outputTools, t.Name)
+	if s.readOnly {
+		if !writesOutput && !t.readOnly() {
+			s.disabled = append(s.disabled, t.Name)
+			return nil
+		}
+		if writesOutput && t.Annotations != nil {
+			// Without output_path the tool only computes a result.
+			a := *t.Annotations
+			a.ReadOnlyHint, a.DestructiveHint, a.IdempotentHint = Hint(true), Hint(false), Hint(true)
+			t.Annotations = &a
+		}
+	}
+	withPathArguments(&t, writesOutput && !s.readOnly)
+	if !s.readOnly {
+		withOutputFields(&t)
+	}
+	s.tools = append(s.tools, t)
+	return nil
+}
+
+// tool returns the registered tool called name.
+func (s *Server) tool(name string) (*Tool, bool) {
+	i := slices.IndexFunc(s.tools, func(t Tool) bool { return t.Name == name })
+	if i < 0 {
+		return nil, false
+	}
+	return &s.tools[i], true
+}
+
+// toolDefinitions lists the registered tools as a client of protocol
+// revision version sees them.
+func (s *Server) toolDefinitions(version string) []toolDefinition {
+	defs := make([]toolDefinition, len(s.tools))
+	for i, t := range s.tools {
+		defs[i] = toolDefinition{Name: t.Name, Description: t.Description, InputSchema: t.InputSchema}
+		if version >= structuredOutputVersion {
+			defs[i].OutputSchema = t.OutputSchema
+		}
+		if version >= annotationsVersion {
+			defs[i].Annotations = t.Annotations
+		}
+	}
+	return defs
+}
+
+// manifest is the manifest.json read by MCP clients that install servers
+// from a description of their tools.
+type manifest struct {
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	Version      string           `json:"version"`
+	License      string           `json:"license"`
+	Tools        []toolDefinition `json:"tools"`
+	Capabilities struct {
+		RateLimits struct {
+			Concurrency int `json:"concurrency"`
+		} `json:"rateLimits"`
+	} `json:"capabilities"`
+}
+
+// WriteManifest writes the manifest of the server and its registered tools,
+// as of the latest protocol revision, to w.
+func (s *Server) WriteManifest(w io.Writer) error {
+	m := manifest{
+		Name:        serverName,
+		Description: "MCP server that compiles, validates, edits and translates gettext PO catalogs, with WordPress output formats.",
+		Version:     serverVersion,
+		License:     "MIT",
+		Tools:       s.toolDefinitions(supportedProtocolVersions[0]),
+	}
+	m.Capabilities.RateLimits.Concurrency = cap(s.slots)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// cloneSchema copies the top level and the properties of an object schema,
+// so they can be extended without changing the caller's maps.
+func cloneSchema(schema map[string]any) map[string]any {
+	schema = maps.Clone(schema)
+	if props, ok := schema["properties"].(map[string]any); ok {
+		schema["properties"] = maps.Clone(props)
+	}
+	return schema
+}
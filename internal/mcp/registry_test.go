@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// manifest.json is generated with -print-manifest; it must list the tools
+// the server actually serves.
+func TestManifestUpToDate(t *testing.T) {
+	want, err := os.ReadFile("../../manifest.json")
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var got bytes.Buffer
+	if err := NewServer().WriteManifest(&got); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("manifest.json is stale; regenerate it with: go run ./cmd/mcp-po-server -print-manifest > manifest.json")
+	}
+}
+
+type greetArgs struct {
+	Name  string   `json:"name" description:"Who to greet"`
+	Times int      `json:"times,omitempty" minimum:"1" default:"1" description:"Repetitions"`
+	Tags  []string `json:"tags,omitempty" enum:"a,b"`
+}
+
+type greetResult struct {
+	Text string `json:"text"`
+}
+
+func greetTool() Tool {
+	return NewTool("greet", "Greet someone", func(ctx context.Context, a greetArgs) (*greetResult, error) {
+		return &greetResult{Text: strings.Repeat("hello "+a.Name+" ", a.Times)}, nil
+	})
+}
+
+func TestNewToolSchemas(t *testing.T) {
+	tool := greetTool()
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "description": "Who to greet"},
+			"times": map[string]any{"type": "integer", "minimum": 1, "default": 1, "description": "Repetitions"},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []string{"a", "b"}}},
+		},
+		"required": []string{"name"},
+	}
+	if !reflect.DeepEqual(tool.InputSchema, want) {
+		t.Fatalf("InputSchema = %v, want %v", tool.InputSchema, want)
+	}
+	if tool.OutputSchema["type"] != "object" {
+		t.Fatalf("OutputSchema = %v, want an object schema", tool.OutputSchema)
+	}
+}
+
+func TestRegisterTool(t *testing.T) {
+	s := NewServer()
+	if err := s.RegisterTool(greetTool()); err != nil {
+		t.Fatalf("RegisterTool: %v", err)
+	}
+	if err := s.RegisterTool(greetTool()); err == nil {
+		t.Fatalf("registering greet twice succeeded")
+	}
+	if err := s.RegisterTool(Tool{Name: "compile_po", Handler: greetTool().Handler}); err == nil {
+		t.Fatalf("replacing a built-in tool succeeded")
+	}
+
+	ctx := withSession(context.Background(), &session{id: "test", protocol: "2025-06-18"}, nil)
+	list := s.handleToolsList(ctx).Tools
+	if last := list[len(list)-1]; last.Name != "greet" || last.OutputSchema == nil {
+		t.Fatalf("tools/list does not end with greet and its outputSchema: %+v", last)
+	}
+
+	params, _ := json.Marshal(map[string]any{"name": "greet", "arguments": map[string]any{"name": "po"}})
+	res, rpcErr := s.handleToolsCall(ctx, &jsonRPCRequest{ID: 1, Params: params})
+	if rpcErr != nil {
+		t.Fatalf("tools/call: %v", rpcErr)
+	}
+	result := res.(callToolResult)
+	if result.IsError || result.StructuredContent["text"] != "hello po " {
+		t.Fatalf("greet result = %+v", result)
+	}
+}
+
+// call runs a tool through tools/call as a 2025-06-18 client.
+func call(t *testing.T, s *Server, name string, args map[string]any) callToolResult {
+	t.Helper()
+	ctx := withSession(context.Background(), &session{id: "test", protocol: "2025-06-18"}, nil)
+	params, _ := json.Marshal(map[string]any{"name": name, "arguments": args})
+	res, rpcErr := s.handleToolsCall(ctx, &jsonRPCRequest{ID: 1, Params: params})
+	if rpcErr != nil {
+		t.Fatalf("tools/call %s: %v", name, rpcErr)
+	}
+	return res.(callToolResult)
+}
+
+func TestBuiltinToolAnnotations(t *testing.T) {
+	for _, tool := range NewServer().tools {
+		a := tool.Annotations
+		if a == nil || a.ReadOnlyHint == nil || a.DestructiveHint == nil || a.IdempotentHint == nil || a.OpenWorldHint == nil {
+			t.Fatalf("%s does not declare all four annotation hints: %+v", tool.Name, a)
+		}
+		// Tools that only write with output_path must not make clients
+		// confirm every call.
+		if destructive := tool.Name == "compile_dir" || tool.Name == "update_entries"; *a.DestructiveHint != destructive {
+			t.Fatalf("%s destructiveHint = %v, want %v", tool.Name, *a.DestructiveHint, destructive)
+		}
+		if *a.ReadOnlyHint && *a.DestructiveHint {
+			t.Fatalf("%s is both read-only and destructive", tool.Name)
+		}
+	}
+}
+
+func TestReadOnlyServer(t *testing.T) {
+	s := NewServer(WithReadOnly())
+	for _, name := range []string{"import_memory", "import_tmx", "compile_dir"} {
+		if _, ok := s.tool(name); ok {
+			t.Fatalf("%s is served by a read-only server", name)
+		}
+		res := call(t, s, name, map[string]any{})
+		if !res.IsError || !strings.Contains(res.Content[0].Text, "disabled on this read-only server") {
+			t.Fatalf("%s call = %+v, want a read-only error", name, res)
+		}
+	}
+
+	compile, ok := s.tool("compile_po")
+	if !ok {
+		t.Fatalf("compile_po is not served by a read-only server")
+	}
+	props := compile.InputSchema["properties"].(map[string]any)
+	for _, arg := range []string{"output_path", "backup", "dry_run"} {
+		if _, ok := props[arg]; ok {
+			t.Fatalf("compile_po accepts %s on a read-only server", arg)
+		}
+	}
+	if !*compile.Annotations.ReadOnlyHint || *compile.Annotations.DestructiveHint {
+		t.Fatalf("compile_po annotations = %+v, want read-only", compile.Annotations)
+	}
+
+	po, err := os.ReadFile("../../test/context-pt_BR.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := call(t, s, "compile_po", map[string]any{"po_content": string(po), "return": "path"}); !res.IsError {
+		t.Fatalf(`compile_po return "path" succeeded on a read-only server`)
+	}
+	if res := call(t, s, "compile_po", map[string]any{"po_content": string(po)}); res.IsError {
+		t.Fatalf("compile_po failed on a read-only server: %s", res.Content[0].Text)
+	}
+}
@@ -0,0 +1,239 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+)
+
+// Catalogs in the workspace are exposed as MCP resources:
+//
+//	po://{project}/{locale}               the catalog itself
+//	po://{project}/{locale}/entries/{id}  one entry, by its stable id
+//
+// The project is the text domain (X-Domain header or the {domain} part of a
+// WordPress file name) and the locale the Language header.
+
+const (
+	resourceScheme  = "po://"
+	catalogMimeType = "text/x-gettext-translation"
+
+	// errResourceNotFound is the JSON-RPC error code MCP assigns to unknown
+	// resources.
+	errResourceNotFound = -32002
+)
+
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int    `json:"size,omitempty"`
+}
+
+type resourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+var resourceTemplates = []resourceTemplate{
+	{
+		URITemplate: "po://{project}/{locale}",
+		Name:        "catalog",
+		Title:       "PO catalog",
+		Description: "A PO catalog in the workspace, by text domain and locale",
+		MimeType:    catalogMimeType,
+	},
+	{
+		URITemplate: "po://{project}/{locale}/entries/{id}",
+		Name:        "catalog-entry",
+		Title:       "PO catalog entry",
+		Description: "One entry of a catalog as JSON, by the stable id list_entries returns",
+		MimeType:    "application/json",
+	},
+}
+
+// catalogFile is a catalog found in the workspace.
+type catalogFile struct {
+	project, locale string
+	path, rel       string
+	content         string
+}
+
+func (c *catalogFile) uri() string {
+	return resourceScheme + url.PathEscape(c.project) + "/" + url.PathEscape(c.locale)
+}
+
+// catalogPath locates the file of a catalog: its root, to name it in
+// listings, and its path.
+type catalogPath struct {
+	root, path string
+}
+
+// loadCatalog reads the catalog file at path inside root.
+func (s *Server) loadCatalog(root, file string) (*catalogFile, error) {
+	content, err := s.readText(file)
+	if err != nil {
+		return nil, err
+	}
+	project, locale := po.CatalogName(content, file)
+	if project == "" {
+		// A file named after the locale alone, as in a theme's languages
+		// folder: the folder names the project.
+		project = filepath.Base(filepath.Dir(file))
+	}
+	rel, _ := filepath.Rel(root, file)
+	return &catalogFile{project: project, locale: locale, path: file, rel: filepath.ToSlash(rel), content: content}, nil
+}
+
+// catalogs lists the catalogs under the workspace roots. When two files
+// share a project and locale, the first one found wins.
+func (s *Server) catalogs() ([]*catalogFile, error) {
+	paths := make(map[string]catalogPath)
+	var out []*catalogFile
+	for _, root := range s.workspace.Roots() {
+		found, err := s.workspace.Find(root, ".po")
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range found.Unreadable {
+			s.logger.Warn("cannot search directory for catalogs", "path", dir)
+		}
+		for _, file := range found.Files {
+			c, err := s.loadCatalog(root, file)
+			if err != nil {
+				continue
+			}
+			if _, seen := paths[c.uri()]; c.locale == "" || seen {
+				continue
+			}
+			paths[c.uri()] = catalogPath{root: root, path: file}
+			out = append(out, c)
+		}
+	}
+	s.catalogMu.Lock()
+	s.catalogPaths = paths
+	s.catalogMu.Unlock()
+	return out, nil
+}
+
+// catalog returns the catalog of a project and locale. The file listed for
+// it last is read directly; the workspace is only searched again when the
+// URI was never listed or its file no longer holds that catalog.
+func (s *Server) catalog(project, locale string) (*catalogFile, error) {
+	uri := (&catalogFile{project: project, locale: locale}).uri()
+	s.catalogMu.Lock()
+	loc, ok := s.catalogPaths[uri]
+	s.catalogMu.Unlock()
+	if ok {
+		if c, err := s.loadCatalog(loc.root, loc.path); err == nil && c.uri() == uri {
+			return c, nil
+		}
+	}
+	catalogs, err := s.catalogs()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range catalogs {
+		if c.uri() == uri {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) handleResourcesList(ctx context.Context) (any, *rpcError) {
+	catalogs, err := s.catalogs()
+	if err != nil {
+		return nil, &rpcError{Code: errInternal, Message: err.Error()}
+	}
+	resources := []resource{}
+	for _, c := range catalogs {
+		r := resource{
+			URI:      c.uri(),
+			Name:     c.rel,
+			Title:    fmt.Sprintf("%s (%s)", c.project, c.locale),
+			MimeType: catalogMimeType,
+			Size:     len(c.content),
+		}
+		if sum, err := s.po.Summarize(ctx, c.content); err == nil {
+			r.Description = fmt.Sprintf("%d messages: %d translated, %d fuzzy, %d untranslated",
+				sum.Total, sum.Translated, sum.Fuzzy, sum.Untranslated)
+		}
+		resources = append(resources, r)
+	}
+	return map[string]any{"resources": resources}, nil
+}
+
+func (s *Server) handleResourcesRead(ctx context.Context, req *jsonRPCRequest) (any, *rpcError) {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return nil, invalidParams("Invalid params: uri is required")
+	}
+	contents, err := s.readResource(ctx, params.URI)
+	if err != nil {
+		return nil, &rpcError{Code: errResourceNotFound, Message: err.Error()}
+	}
+	return map[string]any{"contents": []resourceContents{*contents}}, nil
+}
+
+// resourcePath returns the unescaped path elements of a po:// URI, or nil.
+func resourcePath(uri string) []string {
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return nil
+	}
+	parts := strings.Split(rest, "/")
+	for i, p := range parts {
+		var err error
+		if parts[i], err = url.PathUnescape(p); err != nil {
+			return nil
+		}
+	}
+	return parts
+}
+
+// readResource resolves a po:// URI to the catalog or entry it names.
+func (s *Server) readResource(ctx context.Context, uri string) (*resourceContents, error) {
+	parts := resourcePath(uri)
+	if len(parts) != 2 && (len(parts) != 4 || parts[2] != "entries") {
+		return nil, fmt.Errorf("resource not found: %s", uri)
+	}
+
+	c, err := s.catalog(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("resource not found: %s", uri)
+	}
+	if len(parts) == 2 {
+		return &resourceContents{URI: uri, MimeType: catalogMimeType, Text: c.content}, nil
+	}
+	entry, err := s.po.Entry(ctx, c.content, parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("resource not found: %s: %w", uri, err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &resourceContents{URI: uri, MimeType: "application/json", Text: string(data)}, nil
+}
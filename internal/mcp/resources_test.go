@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
+)
+
+// resourceServer serves a workspace holding the Spanish test catalog.
+func resourceServer(t *testing.T) (*Server, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	content, err := os.ReadFile("../../test/scp-pinterest-es_ES.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "languages"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "languages", "scp-pinterest-es_ES.po"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := workspace.New(dir)
+	if err != nil {
+		t.Fatalf("workspace.New: %v", err)
+	}
+	return NewServer(WithWorkspace(ws)), dir, string(content)
+}
+
+func readURI(s *Server, uri string) (*resourceContents, *rpcError) {
+	params, _ := json.Marshal(map[string]string{"uri": uri})
+	res, rpcErr := s.handleResourcesRead(context.Background(), &jsonRPCRequest{ID: 1, Params: params})
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	contents := res.(map[string]any)["contents"].([]resourceContents)
+	return &contents[0], nil
+}
+
+func TestResourcesListAndRead(t *testing.T) {
+	s, _, content := resourceServer(t)
+	const uri = "po://scp-pinterest/es_ES"
+
+	res, rpcErr := s.handleResourcesList(context.Background())
+	if rpcErr != nil {
+		t.Fatalf("resources/list: %v", rpcErr)
+	}
+	list := res.(map[string]any)["resources"].([]resource)
+	if len(list) != 1 || list[0].URI != uri || list[0].Name != "languages/scp-pinterest-es_ES.po" || !strings.Contains(list[0].Description, "18 translated") {
+		t.Fatalf("unexpected resources: %+v", list)
+	}
+
+	got, rpcErr := readURI(s, uri)
+	if rpcErr != nil || got.Text != content || got.MimeType != catalogMimeType {
+		t.Fatalf("resources/read %s = %+v, %v", uri, got, rpcErr)
+	}
+
+	cat, _ := po.ParseCatalog(content)
+	price := cat.Find(nil, "Price")
+	got, rpcErr = readURI(s, uri+"/entries/"+price.ID())
+	if rpcErr != nil || got.MimeType != "application/json" {
+		t.Fatalf("resources/read entry = %+v, %v", got, rpcErr)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(got.Text), &entry); err != nil || entry["msgid"] != "Price" || entry["id"] != price.ID() {
+		t.Fatalf("entry resource = %s (%v)", got.Text, err)
+	}
+
+	for _, missing := range []string{"po://scp-pinterest/fr_FR", uri + "/entries/000000000000", uri + "/other/x", "file:///etc/passwd"} {
+		if _, rpcErr := readURI(s, missing); rpcErr == nil || rpcErr.Code != errResourceNotFound {
+			t.Fatalf("resources/read %s = %v, want a not-found error", missing, rpcErr)
+		}
+	}
+}
+
+// A listed catalog is read from its file without searching the workspace,
+// and found again by a search when its file moved.
+func TestResourcesReadResolvesListedFile(t *testing.T) {
+	s, dir, content := resourceServer(t)
+	const uri = "po://scp-pinterest/es_ES"
+
+	if got, rpcErr := readURI(s, uri); rpcErr != nil || got.Text != content {
+		t.Fatalf("resources/read before resources/list = %v", rpcErr)
+	}
+
+	// More catalogs than a search accepts: only the listed one stays readable.
+	for i := range workspace.MaxFindResults {
+		name := filepath.Join(dir, fmt.Sprintf("extra-%d.po", i))
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, rpcErr := readURI(s, uri); rpcErr != nil || got.Text != content {
+		t.Fatalf("resources/read of a listed catalog searched the workspace: %v", rpcErr)
+	}
+	if _, rpcErr := readURI(s, "po://scp-pinterest/fr_FR"); rpcErr == nil || !strings.Contains(rpcErr.Message, "more than") {
+		t.Fatalf("resources/read of an unlisted catalog did not search: %v", rpcErr)
+	}
+
+	for i := range workspace.MaxFindResults {
+		_ = os.Remove(filepath.Join(dir, fmt.Sprintf("extra-%d.po", i)))
+	}
+	if err := os.Rename(filepath.Join(dir, "languages", "scp-pinterest-es_ES.po"), filepath.Join(dir, "scp-pinterest-es_ES.po")); err != nil {
+		t.Fatal(err)
+	}
+	if got, rpcErr := readURI(s, uri); rpcErr != nil || got.Text != content {
+		t.Fatalf("resources/read after the file moved = %v", rpcErr)
+	}
+}
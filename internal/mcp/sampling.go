@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+)
+
+type samplingMessage struct {
+	Role    string       `json:"role"`
+	Content contentBlock `json:"content"`
+}
+
+type modelPreferences struct {
+	IntelligencePriority float64 `json:"intelligencePriority"`
+	SpeedPriority        float64 `json:"speedPriority"`
+}
+
+type createMessageParams struct {
+	Messages         []samplingMessage `json:"messages"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	IncludeContext   string            `json:"includeContext,omitempty"`
+	Temperature      float64           `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	ModelPreferences *modelPreferences `json:"modelPreferences,omitempty"`
+}
+
+type createMessageResult struct {
+	Role       string       `json:"role"`
+	Content    contentBlock `json:"content"`
+	Model      string       `json:"model"`
+	StopReason string       `json:"stopReason"`
+}
+
+// samplingUnit is the form of a po.TranslationUnit shown to the model.
+type samplingUnit struct {
+	N        int      `json:"n"`
+	Text     string   `json:"text"`
+	Context  string   `json:"context,omitempty"`
+	Comments []string `json:"comments,omitempty"`
+	Plural   bool     `json:"plural,omitempty"`
+	Form     int      `json:"form,omitempty"`
+}
+
+const samplingSystemPrompt = `You are a professional software localizer translating gettext catalogs.
+Translate every string from %s to %s. Keep the tone and length of a user interface.
+Tokens such as ⟦0⟧ stand for placeholders or markup: copy each one exactly once into the translation, moving it if the grammar requires.
+"context" disambiguates the string and "comments" are notes from the developers; never translate them.
+Strings marked "plural" are the plural source of a message, following its singular unless the target language has a single plural form: give the translation the target plural rule selects for the index in "form" (0 when absent).%s
+Reply with a JSON object {"translations": ["..."]} holding one translation per input string, in order, and nothing else.`
+
+// samplingTranslator implements po.Translator by asking the client's model
+// through sampling/createMessage.
+type samplingTranslator struct {
+	server    *Server
+	maxTokens int
+}
+
+// Translate implements po.Translator.
+func (t *samplingTranslator) Translate(ctx context.Context, req *po.TranslationRequest) ([]string, error) {
+	units := make([]samplingUnit, len(req.Units))
+	for i, u := range req.Units {
+		units[i] = samplingUnit{N: i, Text: u.Text, Context: u.Context, Comments: u.Comments, Plural: u.Plural, Form: u.Form}
+	}
+	data, err := json.MarshalIndent(units, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	plurals := ""
+	if req.PluralForms != "" {
+		plurals = "\nThe target language plural rule is: " + req.PluralForms
+	}
+	maxTokens := t.maxTokens
+	if maxTokens <= 0 {
+		maxTokens = answerBudget(req.Units)
+	}
+	params := createMessageParams{
+		Messages: []samplingMessage{{
+			Role:    "user",
+			Content: contentBlock{Type: "text", Text: string(data)},
+		}},
+		SystemPrompt:     fmt.Sprintf(samplingSystemPrompt, req.SourceLanguage, req.TargetLanguage, plurals),
+		IncludeContext:   "none",
+		Temperature:      0.2,
+		MaxTokens:        maxTokens,
+		ModelPreferences: &modelPreferences{IntelligencePriority: 0.8, SpeedPriority: 0.3},
+	}
+
+	var res createMessageResult
+	if err := t.server.call(ctx, "sampling/createMessage", params, &res); err != nil {
+		return nil, err
+	}
+	if res.Content.Type != "text" {
+		return nil, fmt.Errorf("sampling returned %s content, expected text", res.Content.Type)
+	}
+	translations, err := parseTranslations(res.Content.Text)
+	if err != nil {
+		return nil, err
+	}
+	if len(translations) != len(req.Units) {
+		return nil, fmt.Errorf("model returned %d translations for %d strings", len(translations), len(req.Units))
+	}
+	return translations, nil
+}
+
+// answerBudget estimates the tokens an answer translating units needs: a
+// token per source character, which covers expansion and scripts taking a
+// token per character, plus the JSON around each translation.
+func answerBudget(units []po.TranslationUnit) int {
+	budget := 64
+	for _, u := range units {
+		budget += utf8.RuneCountInString(u.Text) + 8
+	}
+	return budget
+}
+
+// parseTranslations extracts {"translations": [...]} from a model answer,
+// tolerating Markdown code fences and surrounding prose.
+func parseTranslations(text string) ([]string, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("model answer holds no JSON object: %.80q", text)
+	}
+	var out struct {
+		Translations []string `json:"translations"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &out); err != nil {
+		return nil, fmt.Errorf("decode model answer: %w", err)
+	}
+	return out.Translations, nil
+}
+
+// translatePO fills a catalog through the client's model.
+func (s *Server) translatePO(ctx context.Context, a translateArgs) (*po.PretranslateResult, error) {
+	if !clientSupports(ctx, "sampling") {
+		return nil, errNoSampling
+	}
+	batchSize := a.BatchSize
+	if batchSize <= 0 {
+		batchSize = 20
+	}
+	tr := &samplingTranslator{server: s, maxTokens: a.MaxTokens}
+	return s.po.Pretranslate(ctx, a.POContent, tr, po.PretranslateOptions{
+		SourceLanguage: a.SourceLanguage,
+		BatchSize:      batchSize,
+		IncludeFuzzy:   a.IncludeFuzzy,
+	})
+}
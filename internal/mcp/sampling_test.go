@@ -0,0 +1,211 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+)
+
+const samplingPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+"Language: es\n"
+
+msgid "Hello %s"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+`
+
+// sampleTranslate calls translate_po over stdio as a client declaring caps,
+// answering every sampling/createMessage with answer. It returns the tool
+// result and the sampling requests received.
+func sampleTranslate(t *testing.T, caps map[string]any, answer func(units []samplingUnit) string) (callToolResult, []createMessageParams) {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	s := NewServer(
+		WithTransport(NewStdioTransport(serverIn, serverOut)),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	t.Cleanup(func() { _ = s.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx)
+		_ = serverOut.Close()
+	}()
+
+	enc := json.NewEncoder(clientOut)
+	dec := json.NewDecoder(clientIn)
+	send := func(msg map[string]any) {
+		t.Helper()
+		msg["jsonrpc"] = "2.0"
+		if err := enc.Encode(msg); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	send(map[string]any{"id": 1, "method": "initialize", "params": map[string]any{
+		"protocolVersion": "2025-06-18",
+		"capabilities":    caps,
+		"clientInfo":      map[string]any{"name": "test", "version": "1"},
+	}})
+	var initialized map[string]any
+	if err := dec.Decode(&initialized); err != nil || initialized["result"] == nil {
+		t.Fatalf("initialize: %v %v", initialized, err)
+	}
+	send(map[string]any{"method": "notifications/initialized"})
+	send(map[string]any{"id": 2, "method": "tools/call", "params": map[string]any{
+		"name":      "translate_po",
+		"arguments": map[string]any{"po_content": samplingPO},
+	}})
+
+	var requests []createMessageParams
+	for {
+		var msg struct {
+			ID     any             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			t.Fatalf("read server message: %v", err)
+		}
+		switch {
+		case msg.Method == "sampling/createMessage":
+			var params createMessageParams
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				t.Fatalf("decode sampling request: %v", err)
+			}
+			requests = append(requests, params)
+			var units []samplingUnit
+			if err := json.Unmarshal([]byte(params.Messages[0].Content.Text), &units); err != nil {
+				t.Fatalf("sampling prompt is not the JSON list of units: %v", err)
+			}
+			send(map[string]any{"id": msg.ID, "result": createMessageResult{
+				Role:       "assistant",
+				Content:    contentBlock{Type: "text", Text: answer(units)},
+				Model:      "test-model",
+				StopReason: "endTurn",
+			}})
+		case msg.ID == float64(2):
+			var res callToolResult
+			if err := json.Unmarshal(msg.Result, &res); err != nil {
+				t.Fatalf("decode tools/call result: %v", err)
+			}
+			_ = clientOut.Close()
+			if err := <-served; err != nil {
+				t.Fatalf("serve: %v", err)
+			}
+			return res, requests
+		}
+	}
+}
+
+// spanish translates the units of samplingPO as a model would.
+func spanish(units []samplingUnit) []string {
+	r := strings.NewReplacer("Hello", "Hola", "files", "archivos", "file", "archivo")
+	out := make([]string, len(units))
+	for i, u := range units {
+		out[i] = r.Replace(u.Text)
+	}
+	return out
+}
+
+func answerJSON(translations []string) string {
+	data, _ := json.Marshal(map[string]any{"translations": translations})
+	return string(data)
+}
+
+func TestTranslatePOSampling(t *testing.T) {
+	res, requests := sampleTranslate(t, map[string]any{"sampling": map[string]any{}}, func(units []samplingUnit) string {
+		return "Here you go:\n```json\n" + answerJSON(spanish(units)) + "\n```"
+	})
+	if res.IsError {
+		t.Fatalf("translate_po: %s", res.Content[0].Text)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected one sampling request, got %d", len(requests))
+	}
+	req := requests[0]
+	if !strings.Contains(req.SystemPrompt, "from en to es") || !strings.Contains(req.SystemPrompt, "nplurals=2") {
+		t.Fatalf("system prompt lacks the languages or the plural rule:\n%s", req.SystemPrompt)
+	}
+	var units []samplingUnit
+	_ = json.Unmarshal([]byte(req.Messages[0].Content.Text), &units)
+	if len(units) != 3 || units[0].Text != "Hello ⟦0⟧" || !units[2].Plural || units[2].Form != 1 {
+		t.Fatalf("unexpected units: %+v", units)
+	}
+	if req.MaxTokens <= 0 || req.MaxTokens > 256 {
+		t.Fatalf("maxTokens = %d, want a budget sized from three short strings", req.MaxTokens)
+	}
+
+	cat, err := po.ParseCatalog(res.StructuredContent["po_content"].(string))
+	if err != nil {
+		t.Fatalf("result does not parse: %v", err)
+	}
+	if e := cat.Find(nil, "Hello %s"); e.Msgstr[0] != "Hola %s" || !e.IsFuzzy() {
+		t.Fatalf("unexpected translation: %+v", e)
+	}
+	if e := cat.Find(nil, "%d file"); strings.Join(e.Msgstr, "|") != "%d archivo|%d archivos" || !e.IsFuzzy() {
+		t.Fatalf("unexpected plural translation: %q", e.Msgstr)
+	}
+}
+
+func TestTranslatePORejectsDamagedPlaceholders(t *testing.T) {
+	res, _ := sampleTranslate(t, map[string]any{"sampling": map[string]any{}}, func(units []samplingUnit) string {
+		out := spanish(units)
+		out[0] = "Hola" // placeholder dropped
+		return answerJSON(out)
+	})
+	if res.IsError {
+		t.Fatalf("translate_po: %s", res.Content[0].Text)
+	}
+	got := res.StructuredContent
+	if got["translated"] != float64(1) || !strings.Contains(compact(got["warnings"]), "Hello %s") {
+		t.Fatalf("the damaged entry must be skipped with a warning: %v", got)
+	}
+}
+
+func TestTranslatePOBadAnswers(t *testing.T) {
+	cases := []struct {
+		answer string
+		want   string
+	}{
+		{"I cannot translate this.", "holds no JSON object"},
+		{`{"translations": "Hola"}`, "decode model answer"},
+		{answerJSON([]string{"Hola ⟦0⟧"}), "model returned 1 translations for 3 strings"},
+	}
+	for _, c := range cases {
+		res, _ := sampleTranslate(t, map[string]any{"sampling": map[string]any{}}, func([]samplingUnit) string { return c.answer })
+		if !res.IsError || !strings.Contains(res.Content[0].Text, c.want) {
+			t.Fatalf("answer %q: result %+v, want an error containing %q", c.answer, res, c.want)
+		}
+	}
+}
+
+func TestTranslatePOWithoutSampling(t *testing.T) {
+	res, requests := sampleTranslate(t, map[string]any{}, func([]samplingUnit) string { return "" })
+	if !res.IsError || !strings.Contains(res.Content[0].Text, errNoSampling.Error()) || len(requests) != 0 {
+		t.Fatalf("translate_po without sampling = %+v", res)
+	}
+}
+
+func TestAnswerBudget(t *testing.T) {
+	short := answerBudget([]po.TranslationUnit{{Text: "Save"}})
+	long := answerBudget([]po.TranslationUnit{{Text: strings.Repeat("word ", 200)}})
+	if short >= 256 || long < 1000 {
+		t.Fatalf("answerBudget = %d for a word and %d for 1000 characters", short, long)
+	}
+}
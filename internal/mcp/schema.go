@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// writtenFields are the result fields writeOutput removes when it stores
+// them in a file, and outputFields the ones it adds instead.
+var (
+	writtenFields = []string{"po_content", "tmx_content", "mo_base64", "Base64"}
+	outputFields  = map[string]any{
+		"output_path": map[string]any{"type": "string", "description": "File the result was written to"},
+		"backup_path": map[string]any{"type": "string", "description": "Backup of the file output_path replaced"},
+		"dry_run":     map[string]any{"type": "boolean", "description": "Set when nothing was written: output_path, backup_path and output_diff describe what would be"},
+		"change":      map[string]any{"type": "string", "enum": []string{"create", "replace", "unchanged"}, "description": "What a dry run would do to output_path"},
+		"output_diff": map[string]any{"type": "string", "description": "Unified diff from the current content of output_path to the result, for dry runs of text files"},
+	}
+)
+
+// withOutputFields adapts the outputSchema of the tools that produce a
+// file: with output_path set, the file content leaves the result and the
+// path it was written to enters it.
+func withOutputFields(t *Tool) {
+	if t.OutputSchema == nil || !slices.Contains(outputTools, t.Name) {
+		return
+	}
+	t.OutputSchema = cloneSchema(t.OutputSchema)
+	props := t.OutputSchema["properties"].(map[string]any)
+	for name, prop := range outputFields {
+		props[name] = prop
+	}
+	if required, ok := t.OutputSchema["required"].([]string); ok {
+		t.OutputSchema["required"] = slices.DeleteFunc(slices.Clone(required), func(r string) bool { return slices.Contains(writtenFields, r) })
+	}
+}
+
+// structuredContent returns the JSON object form of a result, or nil when it
+// is not an object.
+func structuredContent(result any) map[string]any {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil
+	}
+	var obj map[string]any
+	if json.Unmarshal(data, &obj) != nil {
+		return nil
+	}
+	return obj
+}
+
+// schemaFor derives the JSON Schema of the JSON encoding of t. Fields without
+// omitempty are required; slices, maps and pointers may also be null, since
+// encoding/json writes nil ones as null.
+func schemaFor(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []string{"array", "null"}, "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Struct:
+		props := map[string]any{}
+		required := []string{}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop := schemaFor(f.Type)
+			if f.Type.Kind() == reflect.Pointer {
+				prop = nullable(prop)
+			}
+			props[name] = prop
+			if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+// argumentSchema derives the inputSchema of a tool from its argument struct.
+// Fields are named by their json tag and required unless it says omitempty.
+// The description, enum (comma-separated), default, minimum and maximum tags
+// set the keywords of the same name; on a slice, enum applies to the items.
+func argumentSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return argumentSchema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": argumentSchema(t.Elem())}
+	case reflect.Struct:
+		props := map[string]any{}
+		var required []string
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop := argumentSchema(f.Type)
+			if d := f.Tag.Get("description"); d != "" {
+				prop["description"] = d
+			}
+			if e := f.Tag.Get("enum"); e != "" {
+				target := prop
+				if items, ok := prop["items"].(map[string]any); ok {
+					target = items
+				}
+				target["enum"] = strings.Split(e, ",")
+			}
+			for _, key := range []string{"default", "minimum", "maximum"} {
+				if v, ok := f.Tag.Lookup(key); ok {
+					prop[key] = tagValue(v, f.Type)
+				}
+			}
+			props[name] = prop
+			if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return schemaFor(t)
+	}
+}
+
+// tagValue converts a struct tag value to the type of its field, leaving it
+// a string when it does not parse.
+func tagValue(v string, t reflect.Type) any {
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+func nullable(schema map[string]any) map[string]any {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+	}
+	return schema
+}
@@ -1,15 +1,18 @@
 package mcp
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"os"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+	"github.com/scopweb/mcp-po-compiler-go/internal/workspace"
 )
 
 // JSON-RPC 2.0 structures
@@ -18,13 +21,21 @@ type jsonRPCRequest struct {
 	ID      any             `json:"id,omitempty"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
+
+	// Result and Error are set when the message is a client response to a
+	// server-initiated request.
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *rpcError       `json:"error,omitempty"`
+
+	// hasID tells requests with a null id from notifications.
+	hasID bool
 }
 
 type jsonRPCResponse struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      any         `json:"id,omitempty"`
-	Result  any         `json:"result,omitempty"`
-	Error   *rpcError   `json:"error,omitempty"`
+	JSONRPC string    `json:"jsonrpc"`
+	ID      any       `json:"id"`
+	Result  any       `json:"result,omitempty"`
+	Error   *rpcError `json:"error,omitempty"`
 }
 
 type rpcError struct {
@@ -56,19 +67,25 @@ type toolsListResult struct {
 }
 
 type toolDefinition struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	InputSchema map[string]any `json:"inputSchema"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	InputSchema  map[string]any   `json:"inputSchema"`
+	OutputSchema map[string]any   `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`
 }
 
 type callToolParams struct {
 	Name      string         `json:"name"`
 	Arguments map[string]any `json:"arguments"`
+	Meta      struct {
+		ProgressToken any `json:"progressToken"`
+	} `json:"_meta"`
 }
 
 type callToolResult struct {
-	Content []contentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content           []contentBlock `json:"content"`
+	StructuredContent map[string]any `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
 }
 
 type contentBlock struct {
@@ -76,227 +93,392 @@ type contentBlock struct {
 	Text string `json:"text"`
 }
 
-// Server wires MCP tool handlers to the PO service.
+// Server wires MCP tool handlers to the PO service. Transports connect it to
+// clients; each connection has its own session.
 type Server struct {
-	po     *po.Service
-	mu     sync.Mutex
-	writer io.Writer
+	po        *po.Service
+	workspace *workspace.Workspace
+	transport Transport
+	// slots bounds the requests handled at the same time.
+	slots  chan struct{}
+	logger *slog.Logger
+	tools  []Tool
+	// readOnly withholds every way of writing to disk; disabled lists the
+	// tools it withholds.
+	readOnly bool
+	disabled []string
+	// catalogPaths remembers the file behind each catalog URI listed, so
+	// resources/read does not search the workspace again.
+	catalogMu    sync.Mutex
+	catalogPaths map[string]catalogPath
 }
 
-// NewServer builds a Server with default dependencies.
-func NewServer() *Server {
-	return &Server{
-		po:     po.NewService(),
-		writer: os.Stdout,
+const (
+	serverName    = "mcp-po-compiler"
+	serverVersion = "1.0.2"
+)
+
+// DefaultConcurrency is the number of requests a Server handles at the same
+// time unless WithConcurrency says otherwise.
+const DefaultConcurrency = 4
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithService replaces the default PO service, e.g. to attach a translation
+// memory.
+func WithService(svc *po.Service) Option {
+	return func(s *Server) {
+		s.po = svc
 	}
 }
 
-// Serve starts the MCP server loop over stdio.
-func (s *Server) Serve(ctx context.Context) error {
-	reader := bufio.NewReader(os.Stdin)
+// WithWorkspace confines file arguments (po_path, output_path...) to the
+// roots of ws.
+func WithWorkspace(ws *workspace.Workspace) Option {
+	return func(s *Server) {
+		s.workspace = ws
+	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+// WithTransport selects how clients connect; the default is stdio.
+func WithTransport(t Transport) Option {
+	return func(s *Server) {
+		s.transport = t
+	}
+}
 
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return fmt.Errorf("read error: %w", err)
+// WithConcurrency sets how many requests are handled at the same time;
+// further requests wait for a free slot. ping is always answered at once.
+func WithConcurrency(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.slots = make(chan struct{}, n)
 		}
+	}
+}
 
-		if len(line) == 0 || (len(line) == 1 && line[0] == '\n') {
-			continue
-		}
+// WithReadOnly makes the server read-only: tools that write files are not
+// served, the others lose output_path, and update_entries no longer writes
+// back to po_path. Attach a memory opened with po.OpenMemoryReadOnly so that
+// compiles do not feed it either.
+func WithReadOnly() Option {
+	return func(s *Server) {
+		s.readOnly = true
+	}
+}
+
+// WithLogger sets where the server logs requests and tool calls; the default
+// is slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
 
-		var req jsonRPCRequest
-		if err := json.Unmarshal(line, &req); err != nil {
-			s.sendError(nil, -32700, "Parse error")
-			continue
+// NewServer builds a Server with default dependencies.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		po:        po.NewService(),
+		workspace: &workspace.Workspace{},
+		transport: NewStdioTransport(os.Stdin, os.Stdout),
+		slots:     make(chan struct{}, DefaultConcurrency),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	s.logger = slog.New(newClientHandler(s.logger.Handler()))
+	for _, t := range s.builtinTools() {
+		_ = s.RegisterTool(t) // built-in names are unique
+	}
+	return s
+}
+
+// Serve runs the server on its transport until ctx is cancelled or the
+// transport stops.
+func (s *Server) Serve(ctx context.Context) error {
+	return s.transport.Serve(ctx, s)
+}
+
+// errRequestCancelled is the cause of the context of a request the client
+// cancelled with notifications/cancelled.
+var errRequestCancelled = errors.New("request cancelled by the client")
+
+// handle processes one message of a session. Client responses are routed to
+// the call waiting for them; requests return their response, which is nil
+// for notifications and for requests the client cancelled. Messages sent
+// while handling the request, such as sampling requests, go to out.
+//
+// Requests wait for one of the server's slots, except ping, and run under
+// a context that notifications/cancelled aborts.
+func (s *Server) handle(ctx context.Context, sess *session, req *jsonRPCRequest, out messageWriter) *jsonRPCResponse {
+	if req.isReply() {
+		sess.handleReply(jsonRPCReply{ID: req.ID, Result: req.Result, Error: req.Error})
+		return nil
+	}
+	if req.isNotification() {
+		ctx = withSession(ctx, sess, out)
+		s.logger.DebugContext(ctx, "notification", "session", sess.id, "method", req.Method)
+		_, _ = s.dispatch(ctx, req)
+		return nil
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	key := requestKey(req.ID)
+	sess.mu.Lock()
+	if sess.inflight == nil {
+		sess.inflight = make(map[string]context.CancelCauseFunc)
+	}
+	sess.inflight[key] = cancel
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		delete(sess.inflight, key)
+		sess.mu.Unlock()
+	}()
+
+	if req.Method != "ping" {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
 		}
+	}
 
-		s.handleRequest(ctx, &req)
+	var result any
+	var rpcErr *rpcError
+	ctx = withSession(ctx, sess, out)
+	if err := ctx.Err(); err != nil {
+		rpcErr = &rpcError{Code: errInternal, Message: err.Error()}
+	} else {
+		result, rpcErr = s.dispatch(ctx, req)
+	}
+	attrs := []any{"session", sess.id, "id", req.ID, "method", req.Method, "duration", time.Since(start)}
+	switch {
+	case errors.Is(context.Cause(ctx), errRequestCancelled):
+		s.logger.InfoContext(ctx, "request cancelled", attrs...)
+		return nil
+	case rpcErr != nil:
+		s.logger.WarnContext(ctx, "request failed", append(attrs, "code", rpcErr.Code, "error", rpcErr.Message)...)
+	default:
+		s.logger.DebugContext(ctx, "request", attrs...)
 	}
+	resp := &jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+	if rpcErr != nil {
+		resp.Result = nil
+	}
+	return resp
 }
 
-func (s *Server) handleRequest(ctx context.Context, req *jsonRPCRequest) {
+// dispatch runs the handler of a method.
+func (s *Server) dispatch(ctx context.Context, req *jsonRPCRequest) (any, *rpcError) {
 	switch req.Method {
 	case "initialize":
-		s.handleInitialize(req)
-	case "initialized":
-		// Notification, no response needed
+		var params initializeParams
+		_ = json.Unmarshal(req.Params, &params)
+		sess := sessionFrom(ctx)
+		sess.mu.Lock()
+		sess.protocol = negotiateProtocol(params.ProtocolVersion)
+		sess.clientCaps = params.Capabilities
+		sess.mu.Unlock()
+		return s.handleInitialize(ctx), nil
+	case "notifications/initialized", "notifications/roots/list_changed":
+		// Notifications, no response. Once the client is ready, or when its
+		// roots change, the workspace follows the roots it advertises.
+		if sessionFrom(ctx).clientRoots && clientSupports(ctx, "roots") {
+			go s.refreshRoots(ctx)
+		}
+		return nil, nil
+	case "notifications/cancelled":
+		var params struct {
+			RequestID any `json:"requestId"`
+		}
+		if json.Unmarshal(req.Params, &params) == nil && params.RequestID != nil {
+			sessionFrom(ctx).cancel(params.RequestID)
+		}
+		return nil, nil
 	case "tools/list":
-		s.handleToolsList(req)
+		return s.handleToolsList(ctx), nil
 	case "tools/call":
-		s.handleToolsCall(ctx, req)
+		return s.handleToolsCall(ctx, req)
+	case "resources/list":
+		return s.handleResourcesList(ctx)
+	case "resources/templates/list":
+		return map[string]any{"resourceTemplates": resourceTemplates}, nil
+	case "resources/read":
+		return s.handleResourcesRead(ctx, req)
+	case "prompts/list":
+		return map[string]any{"prompts": prompts}, nil
+	case "prompts/get":
+		return s.handlePromptsGet(ctx, req)
+	case "logging/setLevel":
+		return s.handleSetLevel(ctx, req)
 	case "ping":
-		s.sendResult(req.ID, map[string]any{})
+		return map[string]any{}, nil
 	default:
-		s.sendError(req.ID, -32601, fmt.Sprintf("Method not found: %s", req.Method))
+		return nil, &rpcError{Code: errMethodNotFound, Message: fmt.Sprintf("Method not found: %s", req.Method)}
+	}
+}
+
+// invalidParams is the JSON-RPC error for malformed request parameters.
+func invalidParams(message string) *rpcError {
+	return &rpcError{Code: errInvalidParams, Message: message}
+}
+
+// supportedProtocolVersions lists the MCP revisions the server speaks, newest
+// first.
+var supportedProtocolVersions = []string{"2025-11-25", "2025-06-18", "2025-03-26", "2024-11-05"}
+
+// structuredOutputVersion is the first revision with outputSchema and
+// structuredContent.
+const structuredOutputVersion = "2025-06-18"
+
+// negotiateProtocol returns the version the client requested when the server
+// supports it, and the newest supported one otherwise; the client then
+// decides whether it can continue.
+func negotiateProtocol(requested string) string {
+	if slices.Contains(supportedProtocolVersions, requested) {
+		return requested
 	}
+	return supportedProtocolVersions[0]
 }
 
-func (s *Server) handleInitialize(req *jsonRPCRequest) {
+// structuredOutput reports whether the revision negotiated by the client of
+// ctx carries tool results as structuredContent. Revisions are dates, so they
+// order as strings.
+func structuredOutput(ctx context.Context) bool {
+	sess := sessionFrom(ctx)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.protocol >= structuredOutputVersion
+}
+
+func (s *Server) handleInitialize(ctx context.Context) initializeResult {
+	sess := sessionFrom(ctx)
+	sess.mu.Lock()
+	protocol := sess.protocol
+	sess.mu.Unlock()
 	result := initializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: protocol,
 		Capabilities: map[string]any{
-			"tools": map[string]any{},
-		},
-	}
-	result.ServerInfo.Name = "mcp-po-compiler"
-	result.ServerInfo.Version = "1.0.2"
-	s.sendResult(req.ID, result)
-}
-
-func (s *Server) handleToolsList(req *jsonRPCRequest) {
-	tools := []toolDefinition{
-		{
-			Name:        "compile_po",
-			Description: "Compile a PO file content to MO binary format",
-			InputSchema: map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"po_content": map[string]any{
-						"type":        "string",
-						"description": "The content of the PO file to compile",
-					},
-					"return": map[string]any{
-						"type":        "string",
-						"enum":        []string{"base64", "path"},
-						"default":     "base64",
-						"description": "Return format: base64-encoded MO data or path to temp file",
-					},
-				},
-				"required": []string{"po_content"},
-			},
-		},
-		{
-			Name:        "validate_po",
-			Description: "Validate a PO file content and report warnings",
-			InputSchema: map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"po_content": map[string]any{
-						"type":        "string",
-						"description": "The content of the PO file to validate",
-					},
-				},
-				"required": []string{"po_content"},
-			},
-		},
-		{
-			Name:        "summarize_po",
-			Description: "Summarize translation progress of a PO file",
-			InputSchema: map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"po_content": map[string]any{
-						"type":        "string",
-						"description": "The content of the PO file to summarize",
-					},
-				},
-				"required": []string{"po_content"},
-			},
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+			"prompts":   map[string]any{},
+			"logging":   map[string]any{},
 		},
 	}
-	s.sendResult(req.ID, toolsListResult{Tools: tools})
+	result.ServerInfo.Name = serverName
+	result.ServerInfo.Version = serverVersion
+	return result
+}
+
+func (s *Server) handleToolsList(ctx context.Context) toolsListResult {
+	sess := sessionFrom(ctx)
+	sess.mu.Lock()
+	protocol := sess.protocol
+	sess.mu.Unlock()
+	return toolsListResult{Tools: s.toolDefinitions(protocol)}
 }
 
-func (s *Server) handleToolsCall(ctx context.Context, req *jsonRPCRequest) {
+func (s *Server) handleToolsCall(ctx context.Context, req *jsonRPCRequest) (any, *rpcError) {
 	var params callToolParams
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		s.sendError(req.ID, -32602, "Invalid params")
-		return
+		return nil, invalidParams("Invalid params")
 	}
 
-	var resultText string
-	var isError bool
-
-	switch params.Name {
-	case "compile_po":
-		poContent, _ := params.Arguments["po_content"].(string)
-		returnMode, _ := params.Arguments["return"].(string)
-		if returnMode == "" {
-			returnMode = "base64"
-		}
-		result, err := s.po.Compile(ctx, poContent, returnMode)
-		if err != nil {
-			resultText = fmt.Sprintf("Error: %v", err)
-			isError = true
-		} else {
-			jsonBytes, _ := json.Marshal(result)
-			resultText = string(jsonBytes)
-		}
-
-	case "validate_po":
-		poContent, _ := params.Arguments["po_content"].(string)
-		warnings, summary, err := s.po.Validate(ctx, poContent)
-		if err != nil {
-			resultText = fmt.Sprintf("Error: %v", err)
-			isError = true
-		} else {
-			jsonBytes, _ := json.Marshal(map[string]any{
-				"warnings": warnings,
-				"summary":  summary,
-			})
-			resultText = string(jsonBytes)
-		}
-
-	case "summarize_po":
-		poContent, _ := params.Arguments["po_content"].(string)
-		summary, err := s.po.Summarize(ctx, poContent)
-		if err != nil {
-			resultText = fmt.Sprintf("Error: %v", err)
-			isError = true
-		} else {
-			jsonBytes, _ := json.Marshal(summary)
-			resultText = string(jsonBytes)
-		}
-
-	default:
-		resultText = fmt.Sprintf("Unknown tool: %s", params.Name)
-		isError = true
+	if params.Arguments == nil {
+		params.Arguments = map[string]any{}
 	}
-
-	s.sendResult(req.ID, callToolResult{
-		Content: []contentBlock{{Type: "text", Text: resultText}},
-		IsError: isError,
-	})
+	if token := params.Meta.ProgressToken; token != nil {
+		ctx = po.WithProgress(ctx, progressReporter(ctx, token))
+	}
+	start := time.Now()
+	tool, ok := s.tool(params.Name)
+	var result any
+	err := fmt.Errorf("unknown tool: %s", params.Name)
+	if slices.Contains(s.disabled, params.Name) {
+		err = fmt.Errorf("tool %s writes files and is disabled on this read-only server", params.Name)
+	}
+	if ok {
+		err = checkArguments(tool, params.Arguments)
+	}
+	if err == nil {
+		result, err = s.runTool(ctx, tool, params.Arguments)
+	}
+	attrs := []any{"session", sessionFrom(ctx).id, "id", req.ID, "tool", params.Name,
+		"input_bytes", len(req.Params), "duration", time.Since(start)}
+	if err != nil {
+		s.logger.WarnContext(ctx, "tool failed", append(attrs, "error", err)...)
+	} else {
+		s.logger.InfoContext(ctx, "tool call", attrs...)
+	}
+	return toolResult(ctx, tool, result, err), nil
 }
 
-func (s *Server) sendResult(id any, result any) {
-	resp := jsonRPCResponse{
-		JSONRPC: "2.0",
-		ID:      id,
-		Result:  result,
+// runTool resolves file arguments, runs a tool and stores its output when
+// output_path is set.
+func (s *Server) runTool(ctx context.Context, tool *Tool, args map[string]any) (any, error) {
+	if err := s.loadPathArguments(args); err != nil {
+		return nil, err
+	}
+	outputPath := stringArg(args, "output_path")
+	switch {
+	case tool.Name == "update_entries" && outputPath == "" && !s.readOnly:
+		// Entries edited in a file are written back to it.
+		outputPath = stringArg(args, "po_path")
+	case tool.Name == "compile_po" && (outputPath != "" || boolArg(args, "dry_run")):
+		// output_path writes the compiled bytes itself, and a dry run writes
+		// nothing.
+		args["return"] = "base64"
+	case tool.Name == "compile_po" && s.readOnly && stringArg(args, "return") == "path":
+		return nil, errors.New(`return "path" writes a file and is disabled on this read-only server`)
+	}
+
+	if boolArg(args, "dry_run") {
+		ctx = withDryRun(ctx)
+	}
+	result, err := tool.Handler(ctx, args)
+	if err != nil || outputPath == "" {
+		return result, err
 	}
-	s.send(resp)
+	return s.writeOutput(tool.Name, args, result, outputPath)
 }
 
-func (s *Server) sendError(id any, code int, message string) {
-	resp := jsonRPCResponse{
-		JSONRPC: "2.0",
-		ID:      id,
-		Error:   &rpcError{Code: code, Message: message},
+// toolResult wraps a tool outcome into a tools/call result. Tool failures
+// are reported in-band with isError, not as JSON-RPC errors. Clients of
+// revisions with structured output also get the result as structuredContent;
+// the JSON text block stays for older clients.
+func toolResult(ctx context.Context, tool *Tool, result any, err error) callToolResult {
+	if err != nil {
+		return callToolResult{
+			Content: []contentBlock{{Type: "text", Text: fmt.Sprintf("Error: %v", err)}},
+			IsError: true,
+		}
+	}
+	jsonBytes, _ := json.Marshal(result)
+	res := callToolResult{
+		Content: []contentBlock{{Type: "text", Text: string(jsonBytes)}},
+	}
+	if structuredOutput(ctx) && tool.OutputSchema != nil {
+		res.StructuredContent = structuredContent(result)
 	}
-	s.send(resp)
+	return res
 }
 
-func (s *Server) send(resp jsonRPCResponse) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	data, _ := json.Marshal(resp)
-	fmt.Fprintf(s.writer, "%s\n", data)
+// Close releases the resources of the server, such as the temporary files
+// written by compile_po in "path" mode.
+func (s *Server) Close() error {
+	return s.po.Close()
 }
 
 // CompilePO dispatches the compile_po tool.
 func (s *Server) CompilePO(ctx context.Context, poContent, returnMode string) (*po.CompileResult, error) {
-	return s.po.Compile(ctx, poContent, returnMode)
+	return s.po.Compile(ctx, poContent, po.CompileOptions{Return: returnMode})
 }
 
 // ValidatePO dispatches the validate_po tool.
@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Transcripts in testdata/transcripts are sessions scripted by hand from the
+// MCP specification and replayed over stdio; they are not recordings of real
+// clients. Lines starting with "> " are sent by the client and lines starting
+// with "< " are the messages the server must send, in any order since
+// requests are handled concurrently; "#" starts a comment. In expected
+// messages the string "*" matches any value, and "*array", "*object",
+// "*string" and "*number" any value of that JSON type.
+
+func TestTranscripts(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "transcripts", "*.jsonl"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no transcripts found: %v", err)
+	}
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".jsonl"), func(t *testing.T) {
+			input, want := readTranscript(t, file)
+			got := replay(t, input)
+			for _, w := range want {
+				i := slices.IndexFunc(got, func(g any) bool { return matchJSON(w, g) })
+				if i < 0 {
+					t.Fatalf("no server message matches\n  %s\namong\n  %s", compact(w), compactAll(got))
+				}
+				got = append(got[:i], got[i+1:]...)
+			}
+			if len(got) > 0 {
+				t.Fatalf("unexpected server messages:\n  %s", compactAll(got))
+			}
+		})
+	}
+}
+
+func readTranscript(t *testing.T, file string) (input string, want []any) {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("open transcript: %v", err)
+	}
+	defer f.Close()
+
+	var in strings.Builder
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "> "):
+			in.WriteString(line[2:] + "\n")
+		case strings.HasPrefix(line, "< "):
+			var v any
+			if err := json.Unmarshal([]byte(line[2:]), &v); err != nil {
+				t.Fatalf("%s:%d: invalid expected message: %v", file, n, err)
+			}
+			want = append(want, v)
+		case line == "" || strings.HasPrefix(line, "#"):
+		default:
+			t.Fatalf("%s:%d: line must start with \"> \", \"< \" or \"#\"", file, n)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("read transcript: %v", err)
+	}
+	return in.String(), want
+}
+
+// replay runs a stdio server on input until it is consumed and returns the
+// messages the server wrote.
+func replay(t *testing.T, input string) []any {
+	t.Helper()
+	pr, pw := io.Pipe()
+	s := NewServer(
+		WithTransport(NewStdioTransport(strings.NewReader(input), pw)),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	t.Cleanup(func() { _ = s.Close() })
+
+	var got []any
+	read := make(chan error, 1)
+	go func() {
+		dec := json.NewDecoder(pr)
+		for {
+			var v any
+			if err := dec.Decode(&v); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				read <- err
+				return
+			}
+			got = append(got, v)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Serve(ctx); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	_ = pw.Close()
+	if err := <-read; err != nil {
+		t.Fatalf("server wrote invalid JSON: %v", err)
+	}
+	return got
+}
+
+// matchJSON reports whether got matches want, where "*" in want matches any
+// value, "*array", "*object", "*string" and "*number" any value of that type,
+// and objects must have the same keys.
+func matchJSON(want, got any) bool {
+	switch want {
+	case "*":
+		return true
+	case "*array":
+		_, ok := got.([]any)
+		return ok
+	case "*object":
+		_, ok := got.(map[string]any)
+		return ok
+	case "*string":
+		_, ok := got.(string)
+		return ok
+	case "*number":
+		_, ok := got.(float64)
+		return ok
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for k, wv := range w {
+			gv, ok := g[k]
+			if !ok || !matchJSON(wv, gv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !matchJSON(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(want, got)
+	}
+}
+
+func TestMatchJSON(t *testing.T) {
+	decode := func(s string) any {
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatalf("decode %s: %v", s, err)
+		}
+		return v
+	}
+	cases := []struct {
+		want, got string
+		match     bool
+	}{
+		{`{"id":1,"result":"*"}`, `{"id":1,"result":{"a":[1]}}`, true},
+		{`{"id":1,"result":"*"}`, `{"id":1}`, false},
+		{`{"id":1}`, `{"id":1,"extra":true}`, false},
+		{`{"id":null}`, `{"id":null}`, true},
+		{`[1,"*"]`, `[1,{}]`, true},
+		{`[1,"*"]`, `[1]`, false},
+		{`{"tools":"*array"}`, `{"tools":[]}`, true},
+		{`{"tools":"*array"}`, `{"tools":{}}`, false},
+		{`["*object","*string","*number"]`, `[{},"",0]`, true},
+		{`["*object","*string","*number"]`, `[[],1,"0"]`, false},
+	}
+	for _, c := range cases {
+		if got := matchJSON(decode(c.want), decode(c.got)); got != c.match {
+			t.Fatalf("matchJSON(%s, %s) = %v, want %v", c.want, c.got, got, c.match)
+		}
+	}
+}
+
+func TestDecodeMessagesNotificationVsNullID(t *testing.T) {
+	msgs, batch, resp := decodeMessages([]byte(`{"jsonrpc":"2.0","id":null,"method":"ping"}`))
+	if resp != nil || batch || msgs[0].req == nil || msgs[0].req.isNotification() {
+		t.Fatalf("a null id must make a request, got %+v %v %+v", msgs, batch, resp)
+	}
+	msgs, _, _ = decodeMessages([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	if msgs[0].req == nil || !msgs[0].req.isNotification() {
+		t.Fatalf("a message without id must be a notification, got %+v", msgs)
+	}
+}
+
+func compact(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func compactAll(list []any) string {
+	out := make([]string, len(list))
+	for i, v := range list {
+		out[i] = compact(v)
+	}
+	return strings.Join(out, "\n  ")
+}
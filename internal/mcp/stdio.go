@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Transport connects clients to a Server.
+type Transport interface {
+	// Serve handles clients until ctx is cancelled or the transport fails.
+	Serve(ctx context.Context, s *Server) error
+}
+
+// StdioTransport serves a single client over newline-delimited JSON-RPC,
+// normally on the process stdin and stdout.
+type StdioTransport struct {
+	reader io.Reader
+	writer io.Writer
+	mu     sync.Mutex // serializes writes
+}
+
+// NewStdioTransport returns a transport reading requests from r and writing
+// messages to w.
+func NewStdioTransport(r io.Reader, w io.Writer) *StdioTransport {
+	return &StdioTransport{reader: r, writer: w}
+}
+
+// Serve implements Transport. Requests other than initialize are handled
+// concurrently, so a long tool call neither blocks ping nor the replies and
+// notifications/cancelled it may be waiting for; the Server bounds how many
+// run at once.
+func (t *StdioTransport) Serve(ctx context.Context, s *Server) error {
+	reader := bufio.NewReader(t.reader)
+	// The only client may confine the workspace to its roots.
+	sess := &session{id: "stdio", out: t.write, clientRoots: true}
+
+	// Once the input ends, requests in flight still complete and get their
+	// response, but those waiting on the client fail.
+	var requests sync.WaitGroup
+	defer requests.Wait()
+	defer sess.close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		line, err := reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("read error: %w", err)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		msgs, batch, resp := decodeMessages(line)
+		switch {
+		case resp != nil:
+			s.logger.Warn("invalid message", "transport", "stdio", "input_bytes", len(line), "error", resp.Error.Message)
+			t.reply(resp)
+		case batch:
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				if resps := s.handleBatch(ctx, sess, msgs, t.write); len(resps) > 0 {
+					_ = t.write(resps)
+				}
+			}()
+		case msgs[0].req == nil:
+			t.reply(msgs[0].err)
+		case !msgs[0].req.isNotification() && !msgs[0].req.isReply() && msgs[0].req.Method != "initialize":
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				t.reply(s.handle(ctx, sess, msgs[0].req, t.write))
+			}()
+		default:
+			t.reply(s.handle(ctx, sess, msgs[0].req, t.write))
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func (t *StdioTransport) reply(resp *jsonRPCResponse) {
+	if resp != nil {
+		_ = t.write(resp)
+	}
+}
+
+// write sends one JSON-RPC message as a line.
+func (t *StdioTransport) write(msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err = fmt.Fprintf(t.writer, "%s\n", data)
+	return err
+}
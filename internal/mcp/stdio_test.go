@@ -0,0 +1,243 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stdioClient drives a server over an in-memory stdio transport.
+type stdioClient struct {
+	t    *testing.T
+	enc  *json.Encoder
+	in   io.WriteCloser
+	msgs chan rpcMessage
+	done chan error
+}
+
+// rpcMessage is a JSON-RPC message as the client sees it.
+type rpcMessage = map[string]any
+
+// startStdio serves a server with the extra tools over in-memory pipes, runs
+// the initialize handshake for protocol 2025-06-18 and returns the client.
+func startStdio(t *testing.T, tools []Tool, opts ...Option) *stdioClient {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	opts = append([]Option{
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithTransport(NewStdioTransport(serverIn, serverOut)),
+	}, opts...)
+	s := NewServer(opts...)
+	for _, tool := range tools {
+		if err := s.RegisterTool(tool); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	c := &stdioClient{t: t, enc: json.NewEncoder(clientOut), in: clientOut, msgs: make(chan rpcMessage, 64), done: make(chan error, 1)}
+	go func() {
+		c.done <- s.Serve(ctx)
+		_ = serverOut.Close()
+	}()
+	go func() {
+		defer close(c.msgs)
+		dec := json.NewDecoder(clientIn)
+		for {
+			var msg rpcMessage
+			if dec.Decode(&msg) != nil {
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+
+	c.send(rpcMessage{"id": "init", "method": "initialize", "params": rpcMessage{"protocolVersion": "2025-06-18", "capabilities": rpcMessage{}}})
+	if msg := c.recv(); msg["id"] != "init" || msg["result"] == nil {
+		t.Fatalf("initialize: %v", msg)
+	}
+	c.send(rpcMessage{"method": "notifications/initialized"})
+	return c
+}
+
+func (c *stdioClient) send(msg rpcMessage) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	if err := c.enc.Encode(msg); err != nil {
+		c.t.Fatalf("send: %v", err)
+	}
+}
+
+// recv returns the next message from the server.
+func (c *stdioClient) recv() rpcMessage {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.msgs:
+		if !ok {
+			c.t.Fatalf("the server closed its output")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("no message from the server")
+		return nil
+	}
+}
+
+// close ends the input, waits for the server to stop and returns the
+// messages it still wrote.
+func (c *stdioClient) close() []rpcMessage {
+	c.t.Helper()
+	_ = c.in.Close()
+	if err := <-c.done; err != nil {
+		c.t.Fatalf("serve: %v", err)
+	}
+	var rest []rpcMessage
+	for msg := range c.msgs {
+		rest = append(rest, msg)
+	}
+	return rest
+}
+
+func callRequest(id any, tool string, args rpcMessage) rpcMessage {
+	return rpcMessage{"id": id, "method": "tools/call", "params": rpcMessage{"name": tool, "arguments": args}}
+}
+
+type waitArgs struct {
+	Tag     string `json:"tag"`
+	DelayMS int    `json:"delay_ms,omitempty"`
+}
+
+type waitResult struct {
+	Tag string `json:"tag"`
+}
+
+func TestCancelInFlightToolCall(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	c := startStdio(t, []Tool{NewTool("block", "Block until cancelled", func(ctx context.Context, a waitArgs) (*waitResult, error) {
+		close(started)
+		<-ctx.Done()
+		cancelled <- context.Cause(ctx)
+		return &waitResult{Tag: a.Tag}, nil
+	})})
+
+	c.send(callRequest(7, "block", rpcMessage{"tag": "x"}))
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the tool did not start")
+	}
+	c.send(rpcMessage{"method": "notifications/cancelled", "params": rpcMessage{"requestId": 7, "reason": "user"}})
+	select {
+	case cause := <-cancelled:
+		if !errors.Is(cause, errRequestCancelled) {
+			t.Fatalf("tool context cancelled with %v, want errRequestCancelled", cause)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the tool context was not cancelled")
+	}
+
+	c.send(rpcMessage{"id": 8, "method": "ping"})
+	if msg := c.recv(); msg["id"] != float64(8) {
+		t.Fatalf("expected the ping response, got %v", msg)
+	}
+	if rest := c.close(); len(rest) > 0 {
+		t.Fatalf("a cancelled request was answered: %v", rest)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	const limit, calls = 2, 6
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	c := startStdio(t, []Tool{NewTool("slow", "Wait for release", func(ctx context.Context, a waitArgs) (*waitResult, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		return &waitResult{Tag: a.Tag}, nil
+	})}, WithConcurrency(limit))
+
+	for i := range calls {
+		c.send(callRequest(i, "slow", rpcMessage{"tag": fmt.Sprint(i)}))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Load() < limit && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// Give the queued calls a chance to exceed the limit.
+	time.Sleep(50 * time.Millisecond)
+	if n := running.Load(); n != limit {
+		t.Fatalf("%d calls running, want %d", n, limit)
+	}
+
+	// ping does not wait for a slot.
+	c.send(rpcMessage{"id": "ping", "method": "ping"})
+	if msg := c.recv(); msg["id"] != "ping" {
+		t.Fatalf("expected the ping response while the pool is full, got %v", msg)
+	}
+
+	close(release)
+	for range calls {
+		if msg := c.recv(); msg["result"] == nil {
+			t.Fatalf("call failed: %v", msg)
+		}
+	}
+	if p := peak.Load(); p != limit {
+		t.Fatalf("peak of %d concurrent calls, want %d", p, limit)
+	}
+	c.close()
+}
+
+func TestInterleavedResponsesKeepTheirIDs(t *testing.T) {
+	c := startStdio(t, []Tool{NewTool("echo", "Return the tag after a delay", func(ctx context.Context, a waitArgs) (*waitResult, error) {
+		time.Sleep(time.Duration(a.DelayMS) * time.Millisecond)
+		return &waitResult{Tag: a.Tag}, nil
+	})}, WithConcurrency(8))
+
+	// Later requests finish first; string and number ids are kept apart.
+	want := make(map[string]string)
+	for i := range 8 {
+		var id any = i
+		if i%2 == 1 {
+			id = fmt.Sprint(i)
+		}
+		tag := fmt.Sprintf("tag-%d", i)
+		want[requestKey(id)] = tag
+		c.send(callRequest(id, "echo", rpcMessage{"tag": tag, "delay_ms": (8 - i) * 10}))
+	}
+
+	var order []string
+	for range 8 {
+		msg := c.recv()
+		key := requestKey(msg["id"])
+		result, _ := msg["result"].(map[string]any)
+		structured, _ := result["structuredContent"].(map[string]any)
+		if structured["tag"] != want[key] {
+			t.Fatalf("response %s carries %v, want tag %q", key, msg, want[key])
+		}
+		delete(want, key)
+		order = append(order, key)
+	}
+	if len(want) > 0 {
+		t.Fatalf("no response for %v", want)
+	}
+	if order[0] == requestKey(0) {
+		t.Fatalf("responses were not interleaved: %v", order)
+	}
+	c.close()
+}
@@ -0,0 +1,351 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/scopweb/mcp-po-compiler-go/internal/po"
+)
+
+// Argument structs of the built-in tools. Their tags define the inputSchema
+// (see argumentSchema); the path variants of content arguments and
+// output_path are added by withPathArguments.
+
+type compileArgs struct {
+	POContent string `json:"po_content" description:"The content of the PO file to compile"`
+	Return    string `json:"return,omitempty" enum:"base64,path" default:"base64" description:"Return format: base64-encoded MO data or path to temp file"`
+}
+
+type validateArgs struct {
+	POContent      string `json:"po_content" description:"The content of the PO file to validate"`
+	Glossary       string `json:"glossary,omitempty" description:"Glossary content (TBX or CSV) to enforce terminology"`
+	GlossaryFormat string `json:"glossary_format,omitempty" enum:"tbx,csv" description:"Format of glossary (detected from the content when omitted)"`
+	Project        string `json:"project,omitempty" description:"Project whose glossary is loaded from the glossary directory"`
+}
+
+type summarizeArgs struct {
+	POContent string `json:"po_content" description:"The content of the PO file to summarize"`
+}
+
+type filterArgs struct {
+	POContent   string   `json:"po_content" description:"The content of the PO file to filter"`
+	States      []string `json:"states,omitempty" enum:"translated,untranslated,fuzzy,obsolete" description:"Keep entries in any of these states"`
+	Flags       []string `json:"flags,omitempty" description:"Keep entries carrying all of these flags (e.g. php-format)"`
+	Context     string   `json:"context,omitempty" description:"Keep entries with this exact msgctxt"`
+	Reference   string   `json:"reference,omitempty" description:"Glob over #: reference paths, e.g. src/admin/ or **/*.php"`
+	MsgidRegex  string   `json:"msgid_regex,omitempty" description:"Regular expression matched against msgid and msgid_plural"`
+	MsgstrRegex string   `json:"msgstr_regex,omitempty" description:"Regular expression matched against any msgstr form"`
+	Invert      bool     `json:"invert,omitempty" default:"false" description:"Select entries that do not match the predicates"`
+	AddFlags    []string `json:"add_flags,omitempty" description:"Flags to add to selected entries (e.g. no-wrap)"`
+	RemoveFlags []string `json:"remove_flags,omitempty" description:"Flags to remove from selected entries (e.g. fuzzy)"`
+	KeepAll     bool     `json:"keep_all,omitempty" default:"false" description:"Return the whole catalog with flag edits applied in place instead of only the selected entries"`
+}
+
+type concatArgs struct {
+	POContents []string `json:"po_contents" description:"The contents of the PO files to combine, in priority order"`
+	Names      []string `json:"names,omitempty" description:"Labels for each catalog, used in conflict markers"`
+	MoreThan   int      `json:"more_than,omitempty" minimum:"0" description:"Keep messages present in more than this many catalogs (default 0)"`
+	LessThan   int      `json:"less_than,omitempty" minimum:"0" description:"Keep messages present in fewer than this many catalogs (0 = no limit)"`
+	Unique     bool     `json:"unique,omitempty" default:"false" description:"Keep only messages present in exactly one catalog"`
+	UseFirst   bool     `json:"use_first,omitempty" default:"false" description:"Take the first translation instead of emitting #-#-#-#-# conflict markers"`
+}
+
+// commonArgs only differs from concatArgs by the default threshold.
+type commonArgs struct {
+	POContents []string `json:"po_contents" description:"The contents of the PO files to combine, in priority order"`
+	Names      []string `json:"names,omitempty" description:"Labels for each catalog, used in conflict markers"`
+	MoreThan   int      `json:"more_than,omitempty" minimum:"0" description:"Keep messages present in more than this many catalogs (default 1 when no other threshold is set)"`
+	LessThan   int      `json:"less_than,omitempty" minimum:"0" description:"Keep messages present in fewer than this many catalogs (0 = no limit)"`
+	Unique     bool     `json:"unique,omitempty" default:"false" description:"Keep only messages present in exactly one catalog"`
+	UseFirst   bool     `json:"use_first,omitempty" default:"false" description:"Take the first translation instead of emitting #-#-#-#-# conflict markers"`
+}
+
+type initArgs struct {
+	POTContent     string `json:"pot_content" description:"The content of the POT template"`
+	Locale         string `json:"locale" description:"Target locale, e.g. it_IT or pt_BR"`
+	English        bool   `json:"english,omitempty" default:"false" description:"Pre-fill every msgstr with its msgid (msgen), for English catalogs"`
+	PluralForms    string `json:"plural_forms,omitempty" description:"Plural-Forms header to use instead of the built-in rule for the locale"`
+	LanguageTeam   string `json:"language_team,omitempty" description:"Language-Team header (defaults to the language name)"`
+	LastTranslator string `json:"last_translator,omitempty" description:"Last-Translator header, e.g. Jane Doe <jane@example.com>"`
+}
+
+type pseudoArgs struct {
+	POContent string  `json:"po_content" description:"The content of the POT or PO file to pseudo-localize"`
+	Locale    string  `json:"locale,omitempty" description:"Language header of the result (default en_XA, or ar_XB with mirror)"`
+	Accents   bool    `json:"accents,omitempty" default:"true" description:"Replace ASCII letters with accented look-alikes"`
+	Expansion float64 `json:"expansion,omitempty" minimum:"0" default:"0.3" description:"Pad each string by this fraction of its length"`
+	Brackets  bool    `json:"brackets,omitempty" default:"true" description:"Wrap each string in [ ] markers"`
+	Mirror    bool    `json:"mirror,omitempty" default:"false" description:"Wrap each string in a right-to-left override to test RTL layouts"`
+}
+
+type importMemoryArgs struct {
+	POContent string `json:"po_content" description:"The content of the PO file to import"`
+}
+
+type suggestArgs struct {
+	POContent string  `json:"po_content" description:"The content of the PO file to find suggestions for"`
+	MinScore  float64 `json:"min_score,omitempty" minimum:"0" maximum:"1" default:"0.7" description:"Lowest similarity score reported (1 = exact match)"`
+	Limit     int     `json:"limit,omitempty" minimum:"1" default:"3" description:"Maximum matches per entry"`
+}
+
+type glossaryLookupArgs struct {
+	Text           string `json:"text,omitempty" description:"A term or source string; every glossary term it contains is returned"`
+	Locale         string `json:"locale,omitempty" description:"Only return translations for this locale"`
+	Glossary       string `json:"glossary,omitempty" description:"Glossary content (TBX or CSV)"`
+	GlossaryFormat string `json:"glossary_format,omitempty" enum:"tbx,csv" description:"Format of glossary (detected from the content when omitted)"`
+	Project        string `json:"project,omitempty" description:"Project whose glossary is loaded from the glossary directory"`
+}
+
+type importTMXArgs struct {
+	TMXContent string   `json:"tmx_content" description:"The content of the TMX file to import"`
+	Locales    []string `json:"locales,omitempty" description:"Only import these target languages (default all)"`
+}
+
+type exportTMXArgs struct {
+	POContent      string `json:"po_content,omitempty" description:"The content of the PO file to export; when omitted the translation memory is exported"`
+	Locale         string `json:"locale,omitempty" description:"Translation memory locale to export (default all locales)"`
+	SourceLanguage string `json:"source_language,omitempty" default:"en" description:"Language of the msgid strings"`
+}
+
+type pretranslateArgs struct {
+	POContent      string `json:"po_content" description:"The content of the PO file to pretranslate"`
+	Backend        string `json:"backend,omitempty" default:"memory" description:"Translation backend: memory, or http when an endpoint is configured"`
+	SourceLanguage string `json:"source_language,omitempty" default:"en" description:"Language of the msgid strings"`
+	IncludeFuzzy   bool   `json:"include_fuzzy,omitempty" default:"false" description:"Also retranslate fuzzy entries"`
+	BatchSize      int    `json:"batch_size,omitempty" minimum:"1" default:"50" description:"Maximum strings per backend call"`
+}
+
+type translateArgs struct {
+	POContent      string `json:"po_content" description:"The content of the PO file to translate"`
+	SourceLanguage string `json:"source_language,omitempty" default:"en" description:"Language of the msgid strings"`
+	IncludeFuzzy   bool   `json:"include_fuzzy,omitempty" default:"false" description:"Also retranslate fuzzy entries"`
+	BatchSize      int    `json:"batch_size,omitempty" minimum:"1" default:"20" description:"Maximum strings per sampling request"`
+	MaxTokens      int    `json:"max_tokens,omitempty" minimum:"1" description:"Token limit per sampling request (sized from the source strings of the batch when omitted)"`
+}
+
+type updateArgs struct {
+	POContent string      `json:"po_content" description:"The content of the PO file to edit"`
+	BaseHash  string      `json:"base_hash,omitempty" description:"Content hash the edits were prepared against; the batch is rejected if the catalog changed"`
+	Edits     []entryEdit `json:"edits" description:"Edits applied atomically, each addressing an entry by id or by msgctxt and msgid"`
+}
+
+// entryEdit is po.EntryEdit with the descriptions of its schema.
+type entryEdit struct {
+	ID           string   `json:"id,omitempty" description:"Stable entry id"`
+	Msgctxt      *string  `json:"msgctxt,omitempty" description:"Context of the entry (omit for entries without context)"`
+	Msgid        string   `json:"msgid,omitempty" description:"Source string of the entry"`
+	Msgstr       *string  `json:"msgstr,omitempty" description:"New translation of a singular entry"`
+	MsgstrPlural []string `json:"msgstr_plural,omitempty" description:"New translations of every plural form"`
+	AddFlags     []string `json:"add_flags,omitempty" description:"Flags to add, e.g. fuzzy"`
+	RemoveFlags  []string `json:"remove_flags,omitempty" description:"Flags to remove"`
+	Comments     []string `json:"comments,omitempty" description:"Translator comments to append"`
+}
+
+type listArgs struct {
+	POContent   string   `json:"po_content" description:"The content of the PO file to list"`
+	States      []string `json:"states,omitempty" enum:"translated,untranslated,fuzzy,obsolete" description:"List entries in any of these states"`
+	Flags       []string `json:"flags,omitempty" description:"List entries carrying all of these flags"`
+	Context     string   `json:"context,omitempty" description:"List entries with this exact msgctxt"`
+	Reference   string   `json:"reference,omitempty" description:"Glob over #: reference paths, e.g. src/admin/ or **/*.php"`
+	MsgidRegex  string   `json:"msgid_regex,omitempty" description:"Regular expression matched against msgid and msgid_plural"`
+	MsgstrRegex string   `json:"msgstr_regex,omitempty" description:"Regular expression matched against any msgstr form"`
+	Invert      bool     `json:"invert,omitempty" default:"false" description:"List the entries that do not match"`
+	Cursor      string   `json:"cursor,omitempty" description:"next_cursor of the previous page, listed with the same filters"`
+	Limit       int      `json:"limit,omitempty" minimum:"1" default:"100" description:"Maximum entries per page"`
+	MaxTokens   int      `json:"max_tokens,omitempty" minimum:"1" default:"4000" description:"Approximate token budget per page"`
+}
+
+type compileDirArgs struct {
+	Path    string `json:"path" description:"Directory searched recursively for .po files, or a glob such as languages/**/*.po"`
+	JSON    bool   `json:"json,omitempty" default:"false" description:"Also write {name}-{md5}.json Jed files for the strings used by JavaScript"`
+	PHP     bool   `json:"php,omitempty" default:"false" description:"Also write WordPress 6.5+ {name}.l10n.php files"`
+	Workers int    `json:"workers,omitempty" minimum:"1" maximum:"16" default:"4" description:"Catalogs compiled at the same time"`
+	Force   bool   `json:"force,omitempty" default:"false" description:"Compile catalogs even when they have not changed since the last build"`
+	DryRun  bool   `json:"dry_run,omitempty" default:"false" description:"Report the catalogs that would be compiled and the files they would write, without writing anything"`
+}
+
+// Annotations of the built-in tools. Only the tools that replace files by
+// themselves are destructive: the others return their result, and write it
+// to a file only when the caller asks with output_path, as their description
+// says. Writing the same result again changes nothing, and the translation
+// memory only grows, entry by entry.
+var (
+	readsOnly    = ToolAnnotations{ReadOnlyHint: Hint(true), DestructiveHint: Hint(false), IdempotentHint: Hint(true), OpenWorldHint: Hint(false)}
+	producesFile = ToolAnnotations{ReadOnlyHint: Hint(false), DestructiveHint: Hint(false), IdempotentHint: Hint(true), OpenWorldHint: Hint(false)}
+	fillsMemory  = ToolAnnotations{ReadOnlyHint: Hint(false), DestructiveHint: Hint(false), IdempotentHint: Hint(true), OpenWorldHint: Hint(false)}
+	translates   = ToolAnnotations{ReadOnlyHint: Hint(false), DestructiveHint: Hint(false), IdempotentHint: Hint(false), OpenWorldHint: Hint(true)}
+	editsFile    = ToolAnnotations{ReadOnlyHint: Hint(false), DestructiveHint: Hint(true), IdempotentHint: Hint(false), OpenWorldHint: Hint(false)}
+	writesFiles  = ToolAnnotations{ReadOnlyHint: Hint(false), DestructiveHint: Hint(true), IdempotentHint: Hint(true), OpenWorldHint: Hint(false)}
+)
+
+// builtinTools defines the tools every Server offers, in tools/list order.
+func (s *Server) builtinTools() []Tool {
+	return []Tool{
+		NewTool("compile_po", "Compile a PO file content to MO binary format; when the server has a translation memory, the translated entries are also stored in it",
+			func(ctx context.Context, a compileArgs) (*po.CompileResult, error) {
+				return s.po.Compile(ctx, a.POContent, po.CompileOptions{Return: a.Return, DryRun: isDryRun(ctx)})
+			}).WithAnnotations(producesFile),
+		NewTool("validate_po", "Validate a PO file content and report warnings", s.validatePO).WithAnnotations(readsOnly),
+		NewTool("summarize_po", "Summarize translation progress of a PO file",
+			func(ctx context.Context, a summarizeArgs) (po.Summary, error) {
+				return s.po.Summarize(ctx, a.POContent)
+			}).WithAnnotations(readsOnly),
+		NewTool("filter_po", "Select a subset of PO entries (msgattrib style) and optionally add or remove flags",
+			func(ctx context.Context, a filterArgs) (*po.FilterResult, error) {
+				return s.po.Filter(ctx, a.POContent, po.EntryFilter{
+					States:      a.States,
+					Flags:       a.Flags,
+					Context:     a.Context,
+					Reference:   a.Reference,
+					MsgidRegex:  a.MsgidRegex,
+					MsgstrRegex: a.MsgstrRegex,
+					Invert:      a.Invert,
+				}, po.FilterOptions{AddFlags: a.AddFlags, RemoveFlags: a.RemoveFlags, KeepAll: a.KeepAll})
+			}).WithAnnotations(producesFile),
+		NewTool("concat_po", "Concatenate several PO catalogs (msgcat style), marking conflicting translations fuzzy",
+			func(ctx context.Context, a concatArgs) (*po.CatResult, error) {
+				return s.po.Concat(ctx, a.POContents, a.options())
+			}).WithAnnotations(producesFile),
+		NewTool("common_po", "Keep only the messages shared by several PO catalogs (msgcomm style)",
+			func(ctx context.Context, a commonArgs) (*po.CatResult, error) {
+				return s.po.Common(ctx, a.POContents, concatArgs(a).options())
+			}).WithAnnotations(producesFile),
+		NewTool("init_po", "Create a new locale PO catalog from a POT template (msginit style)",
+			func(ctx context.Context, a initArgs) (*po.InitResult, error) {
+				return s.po.Init(ctx, a.POTContent, a.Locale, po.InitOptions{
+					English:        a.English,
+					PluralForms:    a.PluralForms,
+					LanguageTeam:   a.LanguageTeam,
+					LastTranslator: a.LastTranslator,
+				})
+			}).WithAnnotations(producesFile),
+		NewTool("pseudolocalize_po", "Generate a pseudo-localized catalog from a POT or PO and compile it to MO for UI testing",
+			func(ctx context.Context, a pseudoArgs) (*po.PseudoResult, error) {
+				return s.po.Pseudolocalize(ctx, a.POContent, po.PseudoOptions{
+					Locale:    a.Locale,
+					Accents:   a.Accents,
+					Expansion: a.Expansion,
+					Brackets:  a.Brackets,
+					Mirror:    a.Mirror,
+				})
+			}).WithAnnotations(producesFile),
+		NewTool("import_memory", "Store the translated entries of a PO file in the local translation memory (fails when the server has none)",
+			func(ctx context.Context, a importMemoryArgs) (*po.ImportMemoryResult, error) {
+				return s.po.ImportMemory(ctx, a.POContent)
+			}).WithAnnotations(fillsMemory),
+		NewTool("suggest_translations", "Suggest exact and fuzzy translation memory matches for untranslated entries of a PO file (fails when the server has no translation memory)",
+			func(ctx context.Context, a suggestArgs) (*po.SuggestResult, error) {
+				return s.po.Suggest(ctx, a.POContent, po.SuggestOptions{MinScore: a.MinScore, Limit: a.Limit})
+			}).WithAnnotations(readsOnly),
+		NewTool("glossary_lookup", "Look up glossary terms and their mandated translations for a term or a source string",
+			func(ctx context.Context, a glossaryLookupArgs) (*po.GlossaryLookupResult, error) {
+				glossary, err := s.loadGlossary(a.Glossary, a.GlossaryFormat, a.Project)
+				if err != nil {
+					return nil, err
+				}
+				if glossary == nil {
+					return nil, errors.New("glossary or project is required")
+				}
+				return s.po.GlossaryLookup(ctx, glossary, a.Text, a.Locale), nil
+			}).WithAnnotations(readsOnly),
+		NewTool("import_tmx", "Import a TMX 1.4b document into the local translation memory (fails when the server has none)",
+			func(ctx context.Context, a importTMXArgs) (*po.TMXImportResult, error) {
+				return s.po.ImportTMX(ctx, a.TMXContent, po.TMXImportOptions{Locales: a.Locales})
+			}).WithAnnotations(fillsMemory),
+		NewTool("export_tmx", "Export a PO catalog, or the translation memory of a locale, as TMX 1.4b",
+			func(ctx context.Context, a exportTMXArgs) (*po.TMXExportResult, error) {
+				opts := po.TMXExportOptions{SourceLanguage: a.SourceLanguage}
+				if a.POContent != "" {
+					return s.po.ExportTMX(ctx, a.POContent, opts)
+				}
+				return s.po.ExportMemoryTMX(ctx, a.Locale, opts)
+			}).WithAnnotations(producesFile),
+		NewTool("pretranslate_po", "Fill untranslated entries of a PO file through a machine-translation backend, marking results fuzzy and machine-translated", s.pretranslatePO).WithAnnotations(translates),
+		NewTool("translate_po", "Translate untranslated entries of a PO file with the client's language model (MCP sampling), marking results fuzzy and machine-translated", s.translatePO).WithAnnotations(translates),
+		NewTool("update_entries", "Edit individual entries of a PO file (translations, plural forms, flags, translator comments) and return the updated file with a diff",
+			func(ctx context.Context, a updateArgs) (*po.UpdateResult, error) {
+				edits := make([]po.EntryEdit, len(a.Edits))
+				for i, e := range a.Edits {
+					edits[i] = po.EntryEdit(e)
+				}
+				return s.po.UpdateEntries(ctx, a.POContent, edits, po.UpdateOptions{BaseHash: a.BaseHash})
+			}).WithAnnotations(editsFile),
+		NewTool("list_entries", "List PO entries page by page with their id, comments, flags, references and plural forms, optionally filtered",
+			func(ctx context.Context, a listArgs) (*po.ListResult, error) {
+				return s.po.ListEntries(ctx, a.POContent, po.EntryFilter{
+					States:      a.States,
+					Flags:       a.Flags,
+					Context:     a.Context,
+					Reference:   a.Reference,
+					MsgidRegex:  a.MsgidRegex,
+					MsgstrRegex: a.MsgstrRegex,
+					Invert:      a.Invert,
+				}, po.ListOptions{Cursor: a.Cursor, Limit: a.Limit, MaxTokens: a.MaxTokens})
+			}).WithAnnotations(readsOnly),
+		NewTool("compile_dir", "Compile every PO file under a workspace directory or glob in parallel, writing the MO (and optionally WordPress JSON and PHP) files next to each catalog; unchanged catalogs are skipped; when the server has a translation memory, the translated entries are also stored in it", s.compileDir).WithAnnotations(writesFiles),
+	}
+}
+
+func (a concatArgs) options() po.CatOptions {
+	return po.CatOptions{
+		Names:    a.Names,
+		MoreThan: a.MoreThan,
+		LessThan: a.LessThan,
+		Unique:   a.Unique,
+		UseFirst: a.UseFirst,
+	}
+}
+
+// validateResult is the output of validate_po. Warnings holds the message of
+// each diagnostic, as returned before diagnostics existed.
+type validateResult struct {
+	Warnings    []string        `json:"warnings"`
+	Diagnostics []po.Diagnostic `json:"diagnostics"`
+	Summary     po.Summary      `json:"summary"`
+}
+
+func (s *Server) validatePO(ctx context.Context, a validateArgs) (*validateResult, error) {
+	glossary, err := s.loadGlossary(a.Glossary, a.GlossaryFormat, a.Project)
+	if err != nil {
+		return nil, err
+	}
+	diags, summary, err := s.po.Diagnose(ctx, a.POContent, glossary)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &validateResult{Warnings: make([]string, len(diags)), Diagnostics: diags, Summary: summary}
+	for i, d := range diags {
+		res.Warnings[i] = d.Message
+	}
+	return res, nil
+}
+
+// loadGlossary returns the glossary passed inline or named by project, or nil
+// when the call carries neither.
+func (s *Server) loadGlossary(content, format, project string) (*po.Glossary, error) {
+	if content != "" {
+		return po.ParseGlossary(content, format)
+	}
+	if project != "" {
+		return s.po.ProjectGlossary(project)
+	}
+	return nil, nil
+}
+
+// pretranslatePO resolves the requested backend and fills the catalog.
+func (s *Server) pretranslatePO(ctx context.Context, a pretranslateArgs) (*po.PretranslateResult, error) {
+	backend := a.Backend
+	if backend == "" {
+		backend = "memory"
+	}
+	tr, err := s.po.Translator(backend)
+	if err != nil {
+		return nil, err
+	}
+	return s.po.Pretranslate(ctx, a.POContent, tr, po.PretranslateOptions{
+		SourceLanguage: a.SourceLanguage,
+		BatchSize:      a.BatchSize,
+		IncludeFuzzy:   a.IncludeFuzzy,
+	})
+}
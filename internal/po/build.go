@@ -0,0 +1,176 @@
+package po
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildOptions selects the artifacts Build produces besides the .mo.
+type BuildOptions struct {
+	// JSON produces Jed 1.x files for the strings used by JavaScript, as
+	// loaded by wp_set_script_translations.
+	JSON bool
+	// PHP produces a WordPress 6.5+ .l10n.php translation file.
+	PHP bool
+	// DryRun leaves the translation memory untouched.
+	DryRun bool
+}
+
+// BuildResult holds the compiled artifacts of one catalog.
+type BuildResult struct {
+	MO []byte
+	// JSON maps the md5 of each referenced script path to its Jed file.
+	// WordPress looks for {domain}-{locale}-{md5}.json.
+	JSON map[string][]byte
+	PHP  []byte
+
+	Stats    Summary
+	Warnings []string
+}
+
+// Build compiles a catalog to .mo and, on request, to the JSON and PHP
+// formats WordPress also loads. Like Compile, it feeds the translation memory
+// unless opts.DryRun is set.
+func (s *Service) Build(ctx context.Context, poContent string, opts BuildOptions) (*BuildResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	moBin, stats, err := compileMO(poContent)
+	if err != nil {
+		return nil, err
+	}
+	warnings, _, err := s.Validate(ctx, poContent)
+	if err != nil {
+		return nil, err
+	}
+	res := &BuildResult{MO: moBin, Stats: stats, Warnings: warnings}
+
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.memory != nil && !opts.DryRun {
+		_, _ = s.memory.AddCatalog(cat)
+	}
+	if opts.JSON {
+		if res.JSON, err = buildJed(cat); err != nil {
+			return nil, err
+		}
+	}
+	if opts.PHP {
+		res.PHP = buildL10nPHP(cat)
+	}
+	return res, nil
+}
+
+// buildJed groups the translated entries referenced from .js files by
+// script, one Jed document per script.
+func buildJed(cat *Catalog) (map[string][]byte, error) {
+	scripts := make(map[string][]*Entry)
+	for _, e := range cat.Messages() {
+		if e.State() != StateTranslated {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, ref := range e.References {
+			for _, r := range strings.Fields(ref) {
+				path := referencePath(r)
+				if !strings.HasSuffix(path, ".js") || seen[path] {
+					continue
+				}
+				seen[path] = true
+				scripts[path] = append(scripts[path], e)
+			}
+		}
+	}
+	if len(scripts) == 0 {
+		return nil, nil
+	}
+
+	revision := cat.HeaderField("PO-Revision-Date")
+	if revision == "" {
+		revision = time.Now().UTC().Format("2006-01-02 15:04-0700")
+	}
+	out := make(map[string][]byte, len(scripts))
+	for path, entries := range scripts {
+		messages := map[string]any{
+			"": map[string]string{
+				"domain":       "messages",
+				"lang":         cat.HeaderField("Language"),
+				"plural-forms": cat.HeaderField("Plural-Forms"),
+			},
+		}
+		for _, e := range entries {
+			messages[e.Key()] = e.Msgstr
+		}
+		doc := map[string]any{
+			"translation-revision-date": revision,
+			"generator":                 "mcp-po-compiler",
+			"source":                    path,
+			"domain":                    "messages",
+			"locale_data":               map[string]any{"messages": messages},
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s translations: %w", path, err)
+		}
+		sum := md5.Sum([]byte(path))
+		out[hex.EncodeToString(sum[:])] = data
+	}
+	return out, nil
+}
+
+// buildL10nPHP renders the translated entries as the PHP array WordPress 6.5
+// loads from .l10n.php files: header fields in lower case, and a messages
+// map whose plural translations are joined with NUL.
+func buildL10nPHP(cat *Catalog) []byte {
+	var b strings.Builder
+	b.WriteString("<?php\nreturn [")
+
+	if h := cat.Header(); h != nil && len(h.Msgstr) > 0 {
+		for _, line := range strings.Split(h.Msgstr[0], "\n") {
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "%s=>%s,", phpString(strings.ToLower(strings.TrimSpace(name))), phpString(strings.TrimSpace(value)))
+		}
+	}
+
+	var keys []string
+	messages := make(map[string]string)
+	for _, e := range cat.Messages() {
+		if e.State() != StateTranslated {
+			continue
+		}
+		keys = append(keys, e.Key())
+		messages[e.Key()] = strings.Join(e.Msgstr, "\x00")
+	}
+	sort.Strings(keys)
+
+	b.WriteString("'messages'=>[")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=>%s,", phpString(k), phpString(messages[k]))
+	}
+	b.WriteString("]];\n")
+	return []byte(b.String())
+}
+
+// phpString quotes s as a PHP string literal. Single quotes are used unless
+// s holds the EOT or NUL separators, which need double-quoted escapes.
+func phpString(s string) string {
+	if !strings.ContainsAny(s, "\x00\x04") {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\x00", `\x00`, "\x04", `\x04`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
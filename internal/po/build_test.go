@@ -0,0 +1,105 @@
+package po
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const scriptPO = `msgid ""
+msgstr ""
+"Language: es\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#: build/editor.js:10 src/admin/page.php:3
+msgid "Publish"
+msgstr "Publicar"
+
+#: build/editor.js:12
+msgctxt "block"
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d elemento"
+msgstr[1] "%d elementos"
+
+#: build/editor.js:14
+msgid "Draft"
+msgstr ""
+
+#: src/admin/page.php:9
+msgid "It's done"
+msgstr "Está hecho"
+`
+
+func TestBuildStatsAndMO(t *testing.T) {
+	svc := NewService()
+	res, err := svc.Build(context.Background(), samplePO, BuildOptions{})
+	if err != nil {
+		t.Fatalf("build returned error: %v", err)
+	}
+	if len(res.MO) == 0 || res.JSON != nil || res.PHP != nil {
+		t.Fatalf("expected only an MO file, got %+v", res)
+	}
+	if res.Stats.Language != "es" || res.Stats.Translated == 0 {
+		t.Fatalf("unexpected stats %+v", res.Stats)
+	}
+}
+
+func TestBuildJedForScripts(t *testing.T) {
+	res, err := NewService().Build(context.Background(), scriptPO, BuildOptions{JSON: true})
+	if err != nil {
+		t.Fatalf("build returned error: %v", err)
+	}
+	sum := md5.Sum([]byte("build/editor.js"))
+	data, ok := res.JSON[hex.EncodeToString(sum[:])]
+	if len(res.JSON) != 1 || !ok {
+		t.Fatalf("expected one JSON file for build/editor.js, got %d", len(res.JSON))
+	}
+
+	var doc struct {
+		Source     string `json:"source"`
+		LocaleData struct {
+			Messages map[string]json.RawMessage `json:"messages"`
+		} `json:"locale_data"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Source != "build/editor.js" {
+		t.Fatalf("unexpected source %q", doc.Source)
+	}
+	msgs := doc.LocaleData.Messages
+	if string(msgs["Publish"]) != `["Publicar"]` || string(msgs["block\u0004%d item"]) != `["%d elemento","%d elementos"]` {
+		t.Fatalf("unexpected messages %s", data)
+	}
+	if _, ok := msgs["Draft"]; ok {
+		t.Fatalf("untranslated entries must be left out")
+	}
+	if _, ok := msgs["It's done"]; ok {
+		t.Fatalf("entries used only by PHP must be left out")
+	}
+}
+
+func TestBuildL10nPHP(t *testing.T) {
+	res, err := NewService().Build(context.Background(), scriptPO, BuildOptions{PHP: true})
+	if err != nil {
+		t.Fatalf("build returned error: %v", err)
+	}
+	php := string(res.PHP)
+	for _, want := range []string{
+		"<?php\nreturn [",
+		`'language'=>'es',`,
+		`"block\x04%d item"=>"%d elemento\x00%d elementos",`,
+		`'It\'s done'=>'Está hecho',`,
+	} {
+		if !strings.Contains(php, want) {
+			t.Fatalf("expected %q in:\n%s", want, php)
+		}
+	}
+	if strings.Contains(php, "Draft") || !strings.HasSuffix(php, "]];\n") {
+		t.Fatalf("unexpected PHP file:\n%s", php)
+	}
+}
@@ -0,0 +1,507 @@
+package po
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Entry states reported by Entry.State.
+const (
+	StateTranslated   = "translated"
+	StateUntranslated = "untranslated"
+	StateFuzzy        = "fuzzy"
+	StateObsolete     = "obsolete"
+)
+
+// Catalog is a PO file kept as an ordered list of entries with all comments,
+// flags and references preserved, so it can be edited and written back.
+type Catalog struct {
+	Entries []*Entry
+}
+
+// Entry is a single PO message with its comment block.
+type Entry struct {
+	Comments          []string // translator comments ("# ")
+	ExtractedComments []string // extracted comments ("#.")
+	References        []string // source references ("#:")
+	Flags             []string // flags ("#,")
+	Previous          []string // previous-message lines ("#|"), kept verbatim
+	Msgctxt           string
+	HasContext        bool
+	Msgid             string
+	MsgidPlural       string
+	Msgstr            []string // one element for singular entries, one per form for plurals
+	Obsolete          bool
+}
+
+// ParseCatalog parses PO (or POT) content into a Catalog.
+func ParseCatalog(content string) (*Catalog, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("empty po content")
+	}
+
+	cat := &Catalog{}
+	cur := &Entry{}
+	// last points at the string currently receiving continuation lines.
+	var last *string
+	// seenMsgid is set once the current entry has a msgid, so that a following
+	// comment or keyword starts a new entry.
+	seenMsgid := false
+	lineNo := 0
+
+	flush := func() {
+		if seenMsgid {
+			cat.Entries = append(cat.Entries, cur)
+		} else if len(cur.Comments) > 0 && len(cat.Entries) > 0 {
+			// Trailing comments with no message belong to the previous entry.
+			prev := cat.Entries[len(cat.Entries)-1]
+			prev.Comments = append(prev.Comments, cur.Comments...)
+		}
+		cur = &Entry{}
+		last = nil
+		seenMsgid = false
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(content))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\ufeff"))
+		if line == "" {
+			if seenMsgid {
+				flush()
+			}
+			continue
+		}
+
+		obsolete := false
+		if strings.HasPrefix(line, "#~") {
+			obsolete = true
+			line = strings.TrimSpace(line[2:])
+			if strings.HasPrefix(line, "|") {
+				line = "#" + line
+			}
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if seenMsgid {
+				flush()
+			}
+			last = nil
+			switch {
+			case strings.HasPrefix(line, "#."):
+				cur.ExtractedComments = append(cur.ExtractedComments, strings.TrimSpace(line[2:]))
+			case strings.HasPrefix(line, "#:"):
+				cur.References = append(cur.References, strings.Fields(line[2:])...)
+			case strings.HasPrefix(line, "#,"):
+				for _, f := range strings.Split(line[2:], ",") {
+					if f = strings.TrimSpace(f); f != "" {
+						cur.AddFlag(f)
+					}
+				}
+			case strings.HasPrefix(line, "#|"):
+				cur.Previous = append(cur.Previous, strings.TrimSpace(line[2:]))
+			default:
+				text := line[1:]
+				text = strings.TrimPrefix(text, " ")
+				cur.Comments = append(cur.Comments, text)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "\"") {
+			if last == nil {
+				return nil, fmt.Errorf("line %d: string continuation without keyword", lineNo)
+			}
+			s, err := unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			*last += s
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		value, err := unquote(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			if seenMsgid {
+				flush()
+			}
+			cur.Msgctxt = value
+			cur.HasContext = true
+			last = &cur.Msgctxt
+		case keyword == "msgid":
+			if seenMsgid {
+				flush()
+			}
+			cur.Msgid = value
+			seenMsgid = true
+			last = &cur.Msgid
+		case keyword == "msgid_plural":
+			if !seenMsgid {
+				return nil, fmt.Errorf("line %d: msgid_plural without msgid", lineNo)
+			}
+			cur.MsgidPlural = value
+			last = &cur.MsgidPlural
+		case keyword == "msgstr":
+			if !seenMsgid {
+				return nil, fmt.Errorf("line %d: msgstr without msgid", lineNo)
+			}
+			cur.Msgstr = append(cur.Msgstr[:0], value)
+			last = &cur.Msgstr[0]
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			if !seenMsgid {
+				return nil, fmt.Errorf("line %d: msgstr without msgid", lineNo)
+			}
+			idx, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || idx < 0 || idx > 255 {
+				return nil, fmt.Errorf("line %d: invalid plural index in %s", lineNo, keyword)
+			}
+			for len(cur.Msgstr) <= idx {
+				cur.Msgstr = append(cur.Msgstr, "")
+			}
+			cur.Msgstr[idx] = value
+			last = &cur.Msgstr[idx]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNo, keyword)
+		}
+		if obsolete {
+			cur.Obsolete = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read po content: %w", err)
+	}
+	flush()
+
+	return cat, nil
+}
+
+// Header returns the header entry (empty msgid, no context), or nil.
+func (c *Catalog) Header() *Entry {
+	for _, e := range c.Entries {
+		if e.IsHeader() {
+			return e
+		}
+	}
+	return nil
+}
+
+// Messages returns all entries except the header.
+func (c *Catalog) Messages() []*Entry {
+	out := make([]*Entry, 0, len(c.Entries))
+	for _, e := range c.Entries {
+		if !e.IsHeader() {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// HeaderField returns the value of a header field such as "Language".
+func (c *Catalog) HeaderField(name string) string {
+	hdr := c.Header()
+	if hdr == nil || len(hdr.Msgstr) == 0 {
+		return ""
+	}
+	return extractHeader(hdr.Msgstr[0], name)
+}
+
+// SetHeaderField sets a header field, appending it if missing. A header entry
+// is created when the catalog has none.
+func (c *Catalog) SetHeaderField(name, value string) {
+	hdr := c.Header()
+	if hdr == nil {
+		hdr = &Entry{Msgstr: []string{""}}
+		c.Entries = append([]*Entry{hdr}, c.Entries...)
+	}
+	if len(hdr.Msgstr) == 0 {
+		hdr.Msgstr = []string{""}
+	}
+
+	lines := strings.SplitAfter(hdr.Msgstr[0], "\n")
+	prefix := name + ":"
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), prefix) {
+			lines[i] = prefix + " " + value + "\n"
+			hdr.Msgstr[0] = strings.Join(lines, "")
+			return
+		}
+	}
+	body := hdr.Msgstr[0]
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	hdr.Msgstr[0] = body + prefix + " " + value + "\n"
+}
+
+// Find returns the entry with the given context and msgid, or nil. A nil
+// msgctxt only matches entries without one, so msgctxt "" is a context of
+// its own.
+func (c *Catalog) Find(msgctxt *string, msgid string) *Entry {
+	for _, e := range c.Entries {
+		if e.Msgid == msgid && (msgctxt == nil && !e.HasContext || msgctxt != nil && e.HasContext && e.Msgctxt == *msgctxt) {
+			return e
+		}
+	}
+	return nil
+}
+
+// String serializes the catalog back to PO syntax.
+func (c *Catalog) String() string {
+	var b strings.Builder
+	for i, e := range c.Entries {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		e.write(&b)
+	}
+	return b.String()
+}
+
+// IsHeader reports whether the entry is the catalog header.
+func (e *Entry) IsHeader() bool {
+	return e.Msgid == "" && !e.HasContext && !e.Obsolete
+}
+
+// IsPlural reports whether the entry has plural forms.
+func (e *Entry) IsPlural() bool {
+	return e.MsgidPlural != ""
+}
+
+// Key returns the .mo lookup key: msgid, prefixed by "msgctxt\x04" when the
+// entry has a context.
+func (e *Entry) Key() string {
+	if e.HasContext {
+		return e.Msgctxt + "\x04" + e.Msgid
+	}
+	return e.Msgid
+}
+
+// ID returns a short stable identifier of the entry, derived from its Key so
+// it survives edits to translations, comments and flags.
+func (e *Entry) ID() string {
+	sum := sha256.Sum256([]byte(e.Key()))
+	return hex.EncodeToString(sum[:6])
+}
+
+// HasFlag reports whether the entry carries the given flag.
+func (e *Entry) HasFlag(flag string) bool {
+	for _, f := range e.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFlag adds a flag if it is not already present.
+func (e *Entry) AddFlag(flag string) {
+	if !e.HasFlag(flag) {
+		e.Flags = append(e.Flags, flag)
+	}
+}
+
+// RemoveFlag drops a flag if present.
+func (e *Entry) RemoveFlag(flag string) {
+	out := e.Flags[:0]
+	for _, f := range e.Flags {
+		if f != flag {
+			out = append(out, f)
+		}
+	}
+	e.Flags = out
+}
+
+// IsFuzzy reports whether the entry is marked fuzzy.
+func (e *Entry) IsFuzzy() bool {
+	return e.HasFlag("fuzzy")
+}
+
+// IsTranslated reports whether every msgstr form is non-empty.
+func (e *Entry) IsTranslated() bool {
+	if len(e.Msgstr) == 0 {
+		return false
+	}
+	for _, s := range e.Msgstr {
+		if s == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// State classifies the entry as obsolete, fuzzy, translated or untranslated.
+func (e *Entry) State() string {
+	switch {
+	case e.Obsolete:
+		return StateObsolete
+	case e.IsFuzzy():
+		return StateFuzzy
+	case e.IsTranslated():
+		return StateTranslated
+	default:
+		return StateUntranslated
+	}
+}
+
+// Clone returns a deep copy of the entry.
+func (e *Entry) Clone() *Entry {
+	c := *e
+	c.Comments = append([]string(nil), e.Comments...)
+	c.ExtractedComments = append([]string(nil), e.ExtractedComments...)
+	c.References = append([]string(nil), e.References...)
+	c.Flags = append([]string(nil), e.Flags...)
+	c.Previous = append([]string(nil), e.Previous...)
+	c.Msgstr = append([]string(nil), e.Msgstr...)
+	return &c
+}
+
+// write appends the PO representation of the entry to b.
+func (e *Entry) write(b *strings.Builder) {
+	for _, c := range e.Comments {
+		if c == "" {
+			b.WriteString("#\n")
+		} else {
+			b.WriteString("# " + c + "\n")
+		}
+	}
+	for _, c := range e.ExtractedComments {
+		b.WriteString("#. " + c + "\n")
+	}
+	if len(e.References) > 0 {
+		b.WriteString("#: " + strings.Join(e.References, " ") + "\n")
+	}
+	if len(e.Flags) > 0 {
+		b.WriteString("#, " + strings.Join(e.Flags, ", ") + "\n")
+	}
+
+	prefix := ""
+	if e.Obsolete {
+		prefix = "#~ "
+	}
+	for _, p := range e.Previous {
+		b.WriteString(prefix + "#| " + p + "\n")
+	}
+
+	if e.HasContext {
+		writeKeyword(b, prefix, "msgctxt", e.Msgctxt, false)
+	}
+	writeKeyword(b, prefix, "msgid", e.Msgid, false)
+	if e.IsPlural() {
+		writeKeyword(b, prefix, "msgid_plural", e.MsgidPlural, false)
+		forms := e.Msgstr
+		if len(forms) == 0 {
+			forms = []string{"", ""}
+		}
+		for i, s := range forms {
+			writeKeyword(b, prefix, fmt.Sprintf("msgstr[%d]", i), s, false)
+		}
+		return
+	}
+	msgstr := ""
+	if len(e.Msgstr) > 0 {
+		msgstr = e.Msgstr[0]
+	}
+	writeKeyword(b, prefix, "msgstr", msgstr, e.IsHeader())
+}
+
+// writeKeyword writes a keyword and its quoted value. Values with embedded
+// newlines (and the header) are split after each newline, as msgcat does.
+func writeKeyword(b *strings.Builder, prefix, keyword, value string, multiline bool) {
+	if !multiline && !strings.Contains(strings.TrimSuffix(value, "\n"), "\n") {
+		b.WriteString(prefix + keyword + " " + quote(value) + "\n")
+		return
+	}
+	b.WriteString(prefix + keyword + " \"\"\n")
+	for _, part := range strings.SplitAfter(value, "\n") {
+		if part != "" {
+			b.WriteString(prefix + quote(part) + "\n")
+		}
+	}
+}
+
+// quote escapes s as a PO string literal.
+func quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// unquote parses a PO string literal, resolving C escape sequences.
+func unquote(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("malformed string %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
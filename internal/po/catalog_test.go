@@ -0,0 +1,211 @@
+package po
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const annotatedPO = `# Translator header comment
+msgid ""
+msgstr ""
+"Language: es\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#. Shown on the dashboard
+#: src/admin/dashboard.php:12
+#, php-format
+msgid "Hello %s"
+msgstr "Hola %s"
+
+#: src/admin/settings.php:40 src/public/form.php:8
+#, fuzzy
+msgid "Save"
+msgstr "Guardar"
+
+#: src/public/form.php:9
+msgid "Cancel"
+msgstr ""
+
+msgctxt "menu"
+msgid "File"
+msgid_plural "Files"
+msgstr[0] "Archivo"
+msgstr[1] "Archivos"
+
+#~ msgid "Old"
+#~ msgstr "Viejo"
+`
+
+func TestParseCatalogRoundTrip(t *testing.T) {
+	cat, err := ParseCatalog(annotatedPO)
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	if got := len(cat.Messages()); got != 5 {
+		t.Fatalf("expected 5 messages, got %d", got)
+	}
+	if got := cat.HeaderField("Language"); got != "es" {
+		t.Fatalf("expected language 'es', got '%s'", got)
+	}
+
+	hello := cat.Find(nil, "Hello %s")
+	if hello == nil || !hello.HasFlag("php-format") || hello.ExtractedComments[0] != "Shown on the dashboard" {
+		t.Fatalf("unexpected entry: %+v", hello)
+	}
+	menu := "menu"
+	file := cat.Find(&menu, "File")
+	if file == nil || !file.IsPlural() || file.Msgstr[1] != "Archivos" {
+		t.Fatalf("unexpected plural entry: %+v", file)
+	}
+
+	again, err := ParseCatalog(cat.String())
+	if err != nil {
+		t.Fatalf("reparse returned error: %v", err)
+	}
+	if again.String() != cat.String() {
+		t.Fatalf("round trip is not stable:\n%s\n---\n%s", cat.String(), again.String())
+	}
+	if !strings.Contains(cat.String(), "#~ msgid \"Old\"") {
+		t.Fatalf("obsolete entry lost:\n%s", cat.String())
+	}
+}
+
+func TestEntryStates(t *testing.T) {
+	cat, err := ParseCatalog(annotatedPO)
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	want := map[string]string{
+		"Hello %s": StateTranslated,
+		"Save":     StateFuzzy,
+		"Cancel":   StateUntranslated,
+		"File":     StateTranslated,
+		"Old":      StateObsolete,
+	}
+	for _, e := range cat.Messages() {
+		if got := e.State(); got != want[e.Msgid] {
+			t.Fatalf("entry %q: expected state %s, got %s", e.Msgid, want[e.Msgid], got)
+		}
+	}
+}
+
+func TestFindEmptyContext(t *testing.T) {
+	cat, err := ParseCatalog(`msgctxt ""
+msgid "Open"
+msgstr "Abierto"
+
+msgid "Open"
+msgstr "Abrir"
+`)
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	empty := ""
+	if e := cat.Find(nil, "Open"); e == nil || e.HasContext || e.Msgstr[0] != "Abrir" {
+		t.Fatalf("Find without context = %+v", e)
+	}
+	if e := cat.Find(&empty, "Open"); e == nil || !e.HasContext || e.Msgstr[0] != "Abierto" {
+		t.Fatalf(`Find with msgctxt "" = %+v`, e)
+	}
+	menu := "menu"
+	if e := cat.Find(&menu, "Open"); e != nil {
+		t.Fatalf("Find with an absent context = %+v", e)
+	}
+}
+
+func TestQuoteControlCharacters(t *testing.T) {
+	const s = "bell\a back\b feed\f tab\v\t cr\r nl\n \"q\" \\"
+	quoted := quote(s)
+	if want := `"bell\a back\b feed\f tab\v\t cr\r nl\n \"q\" \\"`; quoted != want {
+		t.Fatalf("quote = %s, want %s", quoted, want)
+	}
+	if got, err := unquote(quoted); err != nil || got != s {
+		t.Fatalf("unquote(quote(s)) = %q, %v; want %q", got, err, s)
+	}
+}
+
+func TestFilterSelectsSubset(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+
+	res, err := svc.Filter(ctx, annotatedPO, EntryFilter{Reference: "src/admin/"}, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filter returned error: %v", err)
+	}
+	if res.Matched != 2 || res.Total != 5 {
+		t.Fatalf("unexpected counts: %+v", res)
+	}
+	if strings.Contains(res.Content, "Cancel") || !strings.Contains(res.Content, "Language: es") {
+		t.Fatalf("unexpected subset:\n%s", res.Content)
+	}
+
+	res, err = svc.Filter(ctx, annotatedPO, EntryFilter{States: []string{StateObsolete}, Invert: true}, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filter returned error: %v", err)
+	}
+	if res.Matched != 4 || strings.Contains(res.Content, "Old") {
+		t.Fatalf("expected obsolete entry to be dropped: %+v", res)
+	}
+
+	res, err = svc.Filter(ctx, annotatedPO, EntryFilter{MsgidRegex: "^Fil"}, FilterOptions{})
+	if err != nil {
+		t.Fatalf("filter returned error: %v", err)
+	}
+	if res.Matched != 1 {
+		t.Fatalf("expected one regex match, got %d", res.Matched)
+	}
+}
+
+func TestGlobRegexpNonASCII(t *testing.T) {
+	for _, c := range []struct {
+		glob, path string
+		match      bool
+	}{
+		{"src/café/*.php", "src/café/menu.php", true},
+		{"src/caf?/*.php", "src/café/menu.php", true},
+		{"src/caf?/*.php", "src/cafe/x/menu.php", false},
+		{"**/über.php", "lib/über.php", true},
+		{"主题/", "主题/模板/页面.php", true},
+	} {
+		re, err := globRegexp(c.glob)
+		if err != nil {
+			t.Fatalf("globRegexp(%q) returned error: %v", c.glob, err)
+		}
+		if got := re.MatchString(c.path); got != c.match {
+			t.Fatalf("glob %q on %q = %v, want %v", c.glob, c.path, got, c.match)
+		}
+	}
+}
+
+func TestFilterRewritesFlags(t *testing.T) {
+	svc := NewService()
+	res, err := svc.Filter(context.Background(), annotatedPO,
+		EntryFilter{States: []string{StateFuzzy}},
+		FilterOptions{RemoveFlags: []string{"fuzzy"}, AddFlags: []string{"no-wrap"}, KeepAll: true})
+	if err != nil {
+		t.Fatalf("filter returned error: %v", err)
+	}
+
+	cat, err := ParseCatalog(res.Content)
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	if len(cat.Messages()) != 5 {
+		t.Fatalf("keep_all should return every entry, got %d", len(cat.Messages()))
+	}
+	save := cat.Find(nil, "Save")
+	if save.IsFuzzy() || !save.HasFlag("no-wrap") {
+		t.Fatalf("flags not rewritten: %v", save.Flags)
+	}
+	if cat.Find(nil, "Hello %s").HasFlag("no-wrap") {
+		t.Fatalf("flags applied to unselected entry")
+	}
+}
+
+func TestFilterRejectsUnknownState(t *testing.T) {
+	svc := NewService()
+	if _, err := svc.Filter(context.Background(), annotatedPO, EntryFilter{States: []string{"done"}}, FilterOptions{}); err == nil {
+		t.Fatalf("expected error for unknown state")
+	}
+}
@@ -0,0 +1,253 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CatOptions controls how Concat and Common combine catalogs.
+type CatOptions struct {
+	// Names label each input in conflict markers; defaults to "catalog-N".
+	Names []string
+	// MoreThan keeps messages present in more than this many catalogs.
+	MoreThan int
+	// LessThan keeps messages present in fewer than this many catalogs;
+	// zero means no upper bound.
+	LessThan int
+	// Unique keeps messages present in exactly one catalog (LessThan = 2).
+	Unique bool
+	// UseFirst takes the first non-empty translation instead of emitting
+	// "#-#-#-#-#" conflict markers.
+	UseFirst bool
+}
+
+// CatResult is the output of Concat and Common.
+type CatResult struct {
+	Content   string   `json:"po_content"`
+	Messages  int      `json:"messages"`
+	Conflicts int      `json:"conflicts"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// Concat joins catalogs the way GNU msgcat does: every message appears once,
+// differing translations are kept side by side between "#-#-#-#-#" markers
+// and flagged fuzzy, and headers are reconciled onto the first one.
+func (s *Service) Concat(ctx context.Context, poContents []string, opts CatOptions) (*CatResult, error) {
+	return catenate(ctx, poContents, opts)
+}
+
+// Common selects messages by how many catalogs share them, like GNU
+// msgcomm. Without any threshold a message must appear in at least two
+// catalogs.
+func (s *Service) Common(ctx context.Context, poContents []string, opts CatOptions) (*CatResult, error) {
+	if opts.MoreThan == 0 && opts.LessThan == 0 && !opts.Unique {
+		opts.MoreThan = 1
+	}
+	return catenate(ctx, poContents, opts)
+}
+
+// catSource is one occurrence of a message in an input catalog.
+type catSource struct {
+	catalog int
+	entry   *Entry
+}
+
+func catenate(ctx context.Context, poContents []string, opts CatOptions) (*CatResult, error) {
+	if len(poContents) == 0 {
+		return nil, errors.New("no catalogs given")
+	}
+	if opts.Unique {
+		opts.LessThan = 2
+	}
+
+	names := make([]string, len(poContents))
+	for i := range names {
+		if i < len(opts.Names) && opts.Names[i] != "" {
+			names[i] = opts.Names[i]
+		} else {
+			names[i] = fmt.Sprintf("catalog-%d", i+1)
+		}
+	}
+
+	res := &CatResult{}
+	out := &Catalog{}
+	var order []string
+	sources := make(map[string][]catSource)
+
+	for i, content := range poContents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cat, err := ParseCatalog(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", names[i], err)
+		}
+		if hdr := cat.Header(); hdr != nil {
+			res.Warnings = append(res.Warnings, mergeHeader(out, hdr, names[i])...)
+		}
+		for _, e := range cat.Messages() {
+			key := e.Key()
+			if e.Obsolete {
+				key = "\x00obsolete\x00" + key
+			}
+			list := sources[key]
+			if len(list) > 0 && list[len(list)-1].catalog == i {
+				continue // duplicate within one catalog: first wins
+			}
+			if list == nil {
+				order = append(order, key)
+			}
+			sources[key] = append(list, catSource{catalog: i, entry: e})
+		}
+		progressf(ctx, i+1, len(poContents), "merged %s", names[i])
+	}
+
+	for _, key := range order {
+		list := sources[key]
+		if list[0].entry.Obsolete {
+			if _, active := sources[strings.TrimPrefix(key, "\x00obsolete\x00")]; active {
+				continue
+			}
+		}
+		if len(list) <= opts.MoreThan || (opts.LessThan > 0 && len(list) >= opts.LessThan) {
+			continue
+		}
+		merged, conflict := mergeSources(list, names, opts.UseFirst)
+		if conflict {
+			res.Conflicts++
+		}
+		out.Entries = append(out.Entries, merged)
+		res.Messages++
+	}
+
+	res.Content = out.String()
+	return res, nil
+}
+
+// mergeHeader folds an input header into the output catalog: the first header
+// is taken as is, later ones only contribute fields the output lacks.
+func mergeHeader(out *Catalog, hdr *Entry, name string) []string {
+	if out.Header() == nil {
+		out.Entries = append(out.Entries, hdr.Clone())
+		return nil
+	}
+
+	var warnings []string
+	body := ""
+	if len(hdr.Msgstr) > 0 {
+		body = hdr.Msgstr[0]
+	}
+	for _, line := range strings.Split(body, "\n") {
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field, value = strings.TrimSpace(field), strings.TrimSpace(value)
+		current := out.HeaderField(field)
+		switch {
+		case current == "":
+			out.SetHeaderField(field, value)
+		case current != value && (field == "Language" || field == "Plural-Forms"):
+			warnings = append(warnings, fmt.Sprintf("%s: %s header %q differs from %q, keeping the first", name, field, value, current))
+		}
+	}
+	return warnings
+}
+
+// mergeSources combines the occurrences of one message. It reports whether
+// the inputs carried conflicting translations.
+func mergeSources(list []catSource, names []string, useFirst bool) (*Entry, bool) {
+	merged := list[0].entry.Clone()
+	merged.RemoveFlag("fuzzy")
+
+	type variant struct {
+		forms []string
+		from  []string
+		fuzzy bool
+	}
+	var variants []*variant
+	byValue := make(map[string]*variant)
+
+	for i, src := range list {
+		e := src.entry
+		if i > 0 {
+			merged.Comments = appendMissing(merged.Comments, e.Comments...)
+			merged.ExtractedComments = appendMissing(merged.ExtractedComments, e.ExtractedComments...)
+			merged.References = appendMissing(merged.References, e.References...)
+			for _, f := range e.Flags {
+				if f != "fuzzy" {
+					merged.AddFlag(f)
+				}
+			}
+			if merged.MsgidPlural == "" {
+				merged.MsgidPlural = e.MsgidPlural
+			}
+		}
+		if !e.IsTranslated() {
+			continue
+		}
+		value := strings.Join(e.Msgstr, "\x00")
+		v, ok := byValue[value]
+		if !ok {
+			v = &variant{forms: e.Msgstr}
+			byValue[value] = v
+			variants = append(variants, v)
+		}
+		v.from = append(v.from, names[src.catalog])
+		v.fuzzy = v.fuzzy || e.IsFuzzy()
+	}
+
+	switch {
+	case len(variants) == 0:
+		if list[0].entry.IsFuzzy() {
+			merged.AddFlag("fuzzy")
+		}
+		return merged, false
+	case len(variants) == 1 || useFirst:
+		merged.Msgstr = append([]string(nil), variants[0].forms...)
+		if variants[0].fuzzy {
+			merged.AddFlag("fuzzy")
+		}
+		return merged, len(variants) > 1
+	}
+
+	nforms := 0
+	for _, v := range variants {
+		if len(v.forms) > nforms {
+			nforms = len(v.forms)
+		}
+	}
+	merged.Msgstr = make([]string, nforms)
+	for i := range merged.Msgstr {
+		parts := make([]string, 0, len(variants))
+		for _, v := range variants {
+			form := ""
+			if i < len(v.forms) {
+				form = v.forms[i]
+			}
+			parts = append(parts, "#-#-#-#-#  "+strings.Join(v.from, ", ")+"  #-#-#-#-#\n"+form)
+		}
+		merged.Msgstr[i] = strings.Join(parts, "\n")
+	}
+	merged.AddFlag("fuzzy")
+	return merged, true
+}
+
+// appendMissing appends the values not already present in list.
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, have := range list {
+			if have == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
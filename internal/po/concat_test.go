@@ -0,0 +1,101 @@
+package po
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const catA = `msgid ""
+msgstr ""
+"Language: es\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#: a.php:1
+msgid "Save"
+msgstr "Guardar"
+
+msgid "Quote"
+msgstr "Presupuesto"
+
+msgid "Only in A"
+msgstr "Solo en A"
+`
+
+const catB = `msgid ""
+msgstr ""
+"Language: es\n"
+"Project-Id-Version: Plugin B\n"
+
+#: b.php:7
+msgid "Save"
+msgstr "Guardar"
+
+msgid "Quote"
+msgstr "Cotización"
+
+msgid "Only in B"
+msgstr ""
+`
+
+func TestConcatMarksConflicts(t *testing.T) {
+	svc := NewService()
+	res, err := svc.Concat(context.Background(), []string{catA, catB}, CatOptions{Names: []string{"a.po", "b.po"}})
+	if err != nil {
+		t.Fatalf("concat returned error: %v", err)
+	}
+	if res.Messages != 4 || res.Conflicts != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	cat, err := ParseCatalog(res.Content)
+	if err != nil {
+		t.Fatalf("output does not parse: %v", err)
+	}
+	if cat.HeaderField("Project-Id-Version") != "Plugin B" || cat.HeaderField("Plural-Forms") == "" {
+		t.Fatalf("headers not reconciled: %q", cat.Header().Msgstr[0])
+	}
+
+	save := cat.Find(nil, "Save")
+	if save.IsFuzzy() || save.Msgstr[0] != "Guardar" || len(save.References) != 2 {
+		t.Fatalf("identical translations should merge cleanly: %+v", save)
+	}
+
+	quote := cat.Find(nil, "Quote")
+	want := "#-#-#-#-#  a.po  #-#-#-#-#\nPresupuesto\n#-#-#-#-#  b.po  #-#-#-#-#\nCotización"
+	if !quote.IsFuzzy() || quote.Msgstr[0] != want {
+		t.Fatalf("unexpected conflict entry: %+v", quote)
+	}
+}
+
+func TestConcatUseFirst(t *testing.T) {
+	svc := NewService()
+	res, err := svc.Concat(context.Background(), []string{catA, catB}, CatOptions{UseFirst: true})
+	if err != nil {
+		t.Fatalf("concat returned error: %v", err)
+	}
+	if !strings.Contains(res.Content, "msgstr \"Presupuesto\"") || strings.Contains(res.Content, "#-#-#") {
+		t.Fatalf("expected first translation to win:\n%s", res.Content)
+	}
+}
+
+func TestCommonThresholds(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+
+	res, err := svc.Common(ctx, []string{catA, catB}, CatOptions{})
+	if err != nil {
+		t.Fatalf("common returned error: %v", err)
+	}
+	if res.Messages != 2 || strings.Contains(res.Content, "Only in") {
+		t.Fatalf("expected only shared messages: %+v", res)
+	}
+
+	res, err = svc.Common(ctx, []string{catA, catB}, CatOptions{Unique: true})
+	if err != nil {
+		t.Fatalf("common returned error: %v", err)
+	}
+	if res.Messages != 2 || !strings.Contains(res.Content, "Only in A") || !strings.Contains(res.Content, "Only in B") {
+		t.Fatalf("expected only unique messages: %+v", res)
+	}
+}
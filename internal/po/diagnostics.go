@@ -0,0 +1,63 @@
+package po
+
+import (
+	"context"
+	"sort"
+)
+
+// Diagnostic codes.
+const (
+	DiagMissingHeader          = "missing_header"
+	DiagUntranslated           = "untranslated"
+	DiagGlossaryTerm           = "glossary_term"
+	DiagGlossaryDoNotTranslate = "glossary_do_not_translate"
+)
+
+// Diagnostic is one validation finding, with the fields a client needs to act
+// on it without parsing Message.
+type Diagnostic struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Header is the missing header, for missing_header.
+	Header string `json:"header,omitempty"`
+	// Msgid identifies the entry the finding is about.
+	Msgid string `json:"msgid,omitempty"`
+	// Term is the glossary source term, and Expected its mandated
+	// translations.
+	Term     string   `json:"term,omitempty"`
+	Expected []string `json:"expected,omitempty"`
+}
+
+// Diagnose validates a catalog like Validate and, when g is not nil, checks
+// it against the glossary like CheckGlossary, returning structured findings.
+// Validation findings come first, each group sorted by message.
+func (s *Service) Diagnose(ctx context.Context, poContent string, g *Glossary) ([]Diagnostic, Summary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, Summary{}, err
+	}
+	domain, err := parseDomain(poContent)
+	if err != nil {
+		return nil, Summary{}, err
+	}
+	diags := validateDomain(domain)
+	if g != nil {
+		glossaryDiags, err := checkGlossary(ctx, poContent, g)
+		if err != nil {
+			return nil, Summary{}, err
+		}
+		diags = append(diags, glossaryDiags...)
+	}
+	return diags, summarizeDomain(domain), nil
+}
+
+func sortDiagnostics(diags []Diagnostic) {
+	sort.Slice(diags, func(i, j int) bool { return diags[i].Message < diags[j].Message })
+}
+
+func diagnosticMessages(diags []Diagnostic) []string {
+	out := make([]string, len(diags))
+	for i, d := range diags {
+		out[i] = d.Message
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package po
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	svc := NewService()
+	raw := "msgid \"\"\nmsgstr \"\"\n\"Language: es\\n\"\n\nmsgid \"Hello\"\nmsgstr \"\"\n"
+
+	diags, summary, err := svc.Diagnose(context.Background(), raw, nil)
+	if err != nil {
+		t.Fatalf("diagnose returned error: %v", err)
+	}
+	if len(diags) != 2 || summary.Untranslated != 1 {
+		t.Fatalf("unexpected diagnostics %+v, summary %+v", diags, summary)
+	}
+	if diags[0].Code != DiagMissingHeader || diags[0].Header != "Plural-Forms" {
+		t.Fatalf("unexpected header diagnostic %+v", diags[0])
+	}
+	if diags[1].Code != DiagUntranslated || diags[1].Msgid != "Hello" {
+		t.Fatalf("unexpected entry diagnostic %+v", diags[1])
+	}
+
+	warnings, _, _ := svc.Validate(context.Background(), raw)
+	if len(warnings) != 2 || warnings[0] != diags[0].Message || warnings[1] != diags[1].Message {
+		t.Fatalf("validate warnings %q do not match diagnostics", warnings)
+	}
+}
+
+func TestDiagnoseGlossary(t *testing.T) {
+	g, err := ParseGlossary(glossaryCSV, "csv")
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	diags, _, err := NewService().Diagnose(context.Background(), glossaryPO, g)
+	if err != nil {
+		t.Fatalf("diagnose returned error: %v", err)
+	}
+	var term, dnt *Diagnostic
+	for i := range diags {
+		switch diags[i].Code {
+		case DiagGlossaryTerm:
+			term = &diags[i]
+		case DiagGlossaryDoNotTranslate:
+			dnt = &diags[i]
+		}
+	}
+	if term == nil || term.Term != "quote" || term.Msgid != "Ask for a quote" || len(term.Expected) != 2 {
+		t.Fatalf("unexpected glossary term diagnostic %+v", term)
+	}
+	if dnt == nil || dnt.Term != "Pinterest" || dnt.Msgid != "Share on Pinterest" {
+		t.Fatalf("unexpected do-not-translate diagnostic %+v", dnt)
+	}
+}
@@ -0,0 +1,208 @@
+package po
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffEdits bounds the edit distance the Myers search explores. Its trace
+// grows with the square of the distance, so beyond this many changed lines
+// the differing middle of the texts is replaced as a whole.
+const maxDiffEdits = 1000
+
+// diffOp is one line of an edit script: ' ' kept, '-' deleted, '+' inserted.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// UnifiedDiff returns a unified diff between two texts, or "" when they are
+// equal.
+func UnifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// Walk the script, opening a hunk at the first change and closing it when
+	// more than 2*diffContext unchanged lines follow.
+	aLine, bLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext, len(ops))
+
+		lead := i - start
+		aStart, bStart := aLine-lead, bLine-lead
+		var aCount, bCount int
+		var hunk strings.Builder
+		for _, op := range ops[start:end] {
+			hunk.WriteByte(op.kind)
+			hunk.WriteString(op.line)
+			hunk.WriteByte('\n')
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		out.WriteString(hunk.String())
+
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm. Common
+// leading and trailing lines are trimmed first, which keeps the search small
+// for the few-line edits it is used for. Texts more than maxDiffEdits lines
+// apart get a script deleting every remaining line of a and inserting every
+// remaining line of b.
+func diffLines(a, b []string) []diffOp {
+	var head, tail []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		head = append(head, diffOp{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append(tail, diffOp{' ', a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v[-d..d] as it was before round d.
+	var trace [][]int
+	found := n == 0 && m == 0
+	for d := 0; d <= n+m && !found; d++ {
+		if d > maxDiffEdits {
+			return replaceLines(head, a, b, tail)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	var rev []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			rev = append(rev, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			rev = append(rev, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		rev = append(rev, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	ops := head
+	for i := len(rev) - 1; i >= 0; i-- {
+		ops = append(ops, rev[i])
+	}
+	for i := len(tail) - 1; i >= 0; i-- {
+		ops = append(ops, tail[i])
+	}
+	return ops
+}
+
+// replaceLines is the edit script replacing a by b between head and the
+// reversed tail.
+func replaceLines(head []diffOp, a, b []string, tail []diffOp) []diffOp {
+	ops := head
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	for i := len(tail) - 1; i >= 0; i-- {
+		ops = append(ops, tail[i])
+	}
+	return ops
+}
@@ -0,0 +1,85 @@
+package po
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20\n21\n"
+
+	want := `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -18,3 +18,4 @@
+ 18
+ 19
+ 20
++21
+`
+	if got := UnifiedDiff("a", "b", a, b); got != want {
+		t.Fatalf("unexpected diff:\n%s", got)
+	}
+	if got := UnifiedDiff("a", "b", a, a); got != "" {
+		t.Fatalf("expected empty diff for equal input, got:\n%s", got)
+	}
+}
+
+func TestDiffLinesShortestScript(t *testing.T) {
+	ops := diffLines([]string{"a", "b", "c", "a", "b", "b", "a"}, []string{"c", "b", "a", "b", "a", "c"})
+	edits := 0
+	for _, op := range ops {
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if edits != 5 {
+		t.Fatalf("expected 5 edits (Myers example), got %d: %v", edits, ops)
+	}
+}
+
+func TestDiffLinesLargeChange(t *testing.T) {
+	var a, b []string
+	for i := range 20000 {
+		a = append(a, fmt.Sprintf("msgstr %q", fmt.Sprint("old ", i)))
+		if i%2 == 0 {
+			b = append(b, fmt.Sprintf("msgstr %q", fmt.Sprint("new ", i)))
+		} else {
+			b = append(b, a[i])
+		}
+	}
+	a = append([]string{"header"}, a...)
+	b = append([]string{"header"}, b...)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := diffLines(a, b)
+	runtime.ReadMemStats(&after)
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 64<<20 {
+		t.Fatalf("diffLines allocated %d MiB", alloc>>20)
+	}
+
+	// The script must still turn a into b.
+	var gotA, gotB []string
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+	}
+	if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) || ops[0] != (diffOp{' ', "header"}) {
+		t.Fatalf("edit script does not transform a into b")
+	}
+}
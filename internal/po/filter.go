@@ -0,0 +1,219 @@
+package po
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// EntryFilter holds composable predicates over catalog entries. Every
+// predicate that is set must match; zero values are ignored.
+type EntryFilter struct {
+	States      []string // any of translated, untranslated, fuzzy, obsolete
+	Flags       []string // entry must carry every listed flag
+	Context     string   // exact msgctxt
+	Reference   string   // glob over reference paths; "**" crosses directories, a trailing "/" matches a subtree
+	MsgidRegex  string   // regular expression over msgid and msgid_plural
+	MsgstrRegex string   // regular expression over any msgstr form
+	Invert      bool     // select the entries that do not match
+}
+
+// FilterOptions controls what Filter does with the selected entries.
+type FilterOptions struct {
+	AddFlags    []string
+	RemoveFlags []string
+	// KeepAll returns the whole catalog with flag edits applied to the
+	// selected entries, instead of the selected subset only.
+	KeepAll bool
+}
+
+// FilterResult is the output of Filter.
+type FilterResult struct {
+	Content string `json:"po_content"`
+	Matched int    `json:"matched"`
+	Total   int    `json:"total"`
+}
+
+// Filter selects entries of a catalog (msgattrib style) and optionally
+// rewrites their flags. The header is always kept.
+func (s *Service) Filter(ctx context.Context, poContent string, filter EntryFilter, opts FilterOptions) (*FilterResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	match, err := filter.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Catalog{}
+	res := &FilterResult{}
+	for _, e := range cat.Entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if e.IsHeader() {
+			out.Entries = append(out.Entries, e)
+			continue
+		}
+		res.Total++
+		if !match(e) {
+			if opts.KeepAll {
+				out.Entries = append(out.Entries, e)
+			}
+			continue
+		}
+		res.Matched++
+		for _, f := range opts.RemoveFlags {
+			e.RemoveFlag(f)
+		}
+		for _, f := range opts.AddFlags {
+			e.AddFlag(f)
+		}
+		out.Entries = append(out.Entries, e)
+	}
+
+	res.Content = out.String()
+	return res, nil
+}
+
+// matcher compiles the filter into a predicate.
+func (f EntryFilter) matcher() (func(*Entry) bool, error) {
+	for _, st := range f.States {
+		switch st {
+		case StateTranslated, StateUntranslated, StateFuzzy, StateObsolete:
+		default:
+			return nil, fmt.Errorf("unknown entry state %q", st)
+		}
+	}
+
+	var msgidRe, msgstrRe *regexp.Regexp
+	if f.MsgidRegex != "" {
+		re, err := regexp.Compile(f.MsgidRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid msgid regex: %w", err)
+		}
+		msgidRe = re
+	}
+	if f.MsgstrRegex != "" {
+		re, err := regexp.Compile(f.MsgstrRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid msgstr regex: %w", err)
+		}
+		msgstrRe = re
+	}
+
+	var refRe *regexp.Regexp
+	if f.Reference != "" {
+		re, err := globRegexp(f.Reference)
+		if err != nil {
+			return nil, err
+		}
+		refRe = re
+	}
+
+	return func(e *Entry) bool {
+		ok := f.matches(e, msgidRe, msgstrRe, refRe)
+		if f.Invert {
+			return !ok
+		}
+		return ok
+	}, nil
+}
+
+func (f EntryFilter) matches(e *Entry, msgidRe, msgstrRe, refRe *regexp.Regexp) bool {
+	if len(f.States) > 0 {
+		state := e.State()
+		found := false
+		for _, st := range f.States {
+			if st == state {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, fl := range f.Flags {
+		if !e.HasFlag(fl) {
+			return false
+		}
+	}
+	if f.Context != "" && (!e.HasContext || e.Msgctxt != f.Context) {
+		return false
+	}
+	if msgidRe != nil && !msgidRe.MatchString(e.Msgid) && !(e.IsPlural() && msgidRe.MatchString(e.MsgidPlural)) {
+		return false
+	}
+	if msgstrRe != nil && !slices.ContainsFunc(e.Msgstr, msgstrRe.MatchString) {
+		return false
+	}
+	if refRe != nil {
+		found := false
+		for _, ref := range e.References {
+			if refRe.MatchString(referencePath(ref)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// referencePath strips the ":line" suffix from a "#:" reference.
+func referencePath(ref string) string {
+	if i := strings.LastIndexByte(ref, ':'); i > 0 {
+		if strings.Trim(ref[i+1:], "0123456789") == "" {
+			return ref[:i]
+		}
+	}
+	return ref
+}
+
+// globRegexp converts a path glob into an anchored regular expression. "*"
+// and "?" stay within one path segment, "**" spans directories, and a
+// pattern ending in "/" matches everything below that directory.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	glob := []rune(pattern)
+	for i := 0; i < len(glob); i++ {
+		switch r := glob[i]; r {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				// "**/" also matches zero directories.
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					b.WriteString("(?:.*/)?")
+					i++
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,467 @@
+package po
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryTerm is one terminology entry: a source term with the mandated
+// translation per locale, or a term that must never be translated.
+type GlossaryTerm struct {
+	Source         string              `json:"source"`
+	Targets        map[string][]string `json:"targets,omitempty"` // locale -> accepted translations
+	DoNotTranslate bool                `json:"do_not_translate,omitempty"`
+	Note           string              `json:"note,omitempty"`
+}
+
+// Glossary is a project terminology list loaded from TBX or CSV.
+type Glossary struct {
+	Terms []GlossaryTerm `json:"terms"`
+}
+
+// GlossaryLookupResult is the output of GlossaryLookup.
+type GlossaryLookupResult struct {
+	Locale string              `json:"locale,omitempty"`
+	Terms  []GlossaryTermMatch `json:"terms"`
+}
+
+// GlossaryTermMatch is a glossary term found in looked-up text.
+type GlossaryTermMatch struct {
+	Source         string   `json:"source"`
+	Targets        []string `json:"targets,omitempty"`
+	DoNotTranslate bool     `json:"do_not_translate,omitempty"`
+	Note           string   `json:"note,omitempty"`
+}
+
+// WithGlossaryDir sets the directory holding per-project glossaries, named
+// <project>.tbx or <project>.csv.
+func WithGlossaryDir(dir string) Option {
+	return func(s *Service) {
+		s.glossaryDir = dir
+	}
+}
+
+// ParseGlossary parses a glossary in "tbx" or "csv" format. An empty format
+// is detected from the content.
+//
+// CSV glossaries have a header row with a "source" (or "term") column, one
+// column per locale (es_ES, fr...), and optional "dnt" and "note" columns.
+// Several accepted translations are separated by "|".
+//
+// TBX glossaries (2008 martif and 2019 tbx) take the source term from the
+// document language and one target per langSet/langSec. A descrip, termNote
+// or admin of type "translatability" with value "nonTranslatable", or of type
+// "doNotTranslate" with a true value, marks a do-not-translate term.
+func ParseGlossary(content, format string) (*Glossary, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("empty glossary content")
+	}
+	if format == "" {
+		format = "csv"
+		if strings.HasPrefix(strings.TrimSpace(content), "<") {
+			format = "tbx"
+		}
+	}
+	switch strings.ToLower(format) {
+	case "csv":
+		return parseGlossaryCSV(content)
+	case "tbx":
+		return parseGlossaryTBX(content)
+	default:
+		return nil, fmt.Errorf("unknown glossary format %q (want tbx or csv)", format)
+	}
+}
+
+// ErrGlossaryNotFound is returned by ProjectGlossary when the project has no
+// glossary, or no glossary directory is configured.
+var ErrGlossaryNotFound = errors.New("glossary not found")
+
+// ProjectGlossary loads the glossary of a project from the glossary dir.
+func (s *Service) ProjectGlossary(project string) (*Glossary, error) {
+	if s.glossaryDir == "" {
+		return nil, fmt.Errorf("%w: no glossary directory configured", ErrGlossaryNotFound)
+	}
+	if project == "" || project != filepath.Base(project) || strings.HasPrefix(project, ".") {
+		return nil, fmt.Errorf("invalid project name %q", project)
+	}
+	for _, ext := range []string{".tbx", ".csv"} {
+		data, err := os.ReadFile(filepath.Join(s.glossaryDir, project+ext))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read glossary: %w", err)
+		}
+		return ParseGlossary(string(data), strings.TrimPrefix(ext, "."))
+	}
+	return nil, fmt.Errorf("%w for project %q", ErrGlossaryNotFound, project)
+}
+
+// CheckGlossary reports translations that ignore the glossary: a source term
+// appears in msgid but none of its mandated translations is in msgstr, or a
+// do-not-translate term is missing from msgstr.
+func (s *Service) CheckGlossary(ctx context.Context, poContent string, g *Glossary) ([]string, error) {
+	diags, err := checkGlossary(ctx, poContent, g)
+	if err != nil {
+		return nil, err
+	}
+	return diagnosticMessages(diags), nil
+}
+
+func checkGlossary(ctx context.Context, poContent string, g *Glossary) ([]Diagnostic, error) {
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	locale := cat.HeaderField("Language")
+
+	diags := make([]Diagnostic, 0)
+	for _, e := range cat.Messages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if e.Obsolete || !e.IsTranslated() {
+			continue
+		}
+		for _, term := range g.Terms {
+			if term.DoNotTranslate {
+				// Every form must keep the term, matched like in msgid.
+				for i, msgstr := range e.Msgstr {
+					if containsTerm(formSource(e, i), term.Source, false) && !containsTerm(msgstr, term.Source, false) {
+						diags = append(diags, Diagnostic{
+							Code:    DiagGlossaryDoNotTranslate,
+							Message: fmt.Sprintf("glossary: do-not-translate term %q was translated in entry: %s", term.Source, e.Msgid),
+							Msgid:   e.Msgid,
+							Term:    term.Source,
+						})
+						break
+					}
+				}
+				continue
+			}
+			targets := term.targets(locale)
+			if len(targets) == 0 {
+				continue
+			}
+			// Every form whose source has the term must use a mandated
+			// translation; the first one that does not is reported.
+			for i, msgstr := range e.Msgstr {
+				if !containsTerm(formSource(e, i), term.Source, false) || containsAnyTerm(msgstr, targets) {
+					continue
+				}
+				diags = append(diags, Diagnostic{
+					Code:     DiagGlossaryTerm,
+					Message:  fmt.Sprintf("glossary: term %q should be translated as %q in entry: %s", term.Source, strings.Join(targets, " | "), e.Msgid),
+					Msgid:    e.Msgid,
+					Term:     term.Source,
+					Expected: targets,
+				})
+				break
+			}
+		}
+	}
+	sortDiagnostics(diags)
+	return diags, nil
+}
+
+// containsAnyTerm reports whether text holds one of terms, inflected or not.
+func containsAnyTerm(text string, terms []string) bool {
+	for _, t := range terms {
+		if containsTerm(text, t, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// formSource returns the source text of msgstr form i of e: msgid for the
+// first form, msgid_plural for the others.
+func formSource(e *Entry, i int) string {
+	if i > 0 && e.IsPlural() {
+		return e.MsgidPlural
+	}
+	return e.Msgid
+}
+
+// GlossaryLookup returns the glossary terms that occur in text, or whose
+// source starts with it when text is a single term. Targets are limited to
+// locale when given.
+func (s *Service) GlossaryLookup(ctx context.Context, g *Glossary, text, locale string) *GlossaryLookupResult {
+	res := &GlossaryLookupResult{Locale: NormalizeLocale(locale), Terms: []GlossaryTermMatch{}}
+	query := strings.ToLower(strings.TrimSpace(text))
+	for _, term := range g.Terms {
+		if query != "" && !containsTerm(text, term.Source, false) && !strings.HasPrefix(strings.ToLower(term.Source), query) {
+			continue
+		}
+		m := GlossaryTermMatch{Source: term.Source, DoNotTranslate: term.DoNotTranslate, Note: term.Note}
+		if locale != "" {
+			m.Targets = term.targets(locale)
+		} else {
+			for _, l := range sortedKeys(term.Targets) {
+				for _, t := range term.Targets[l] {
+					m.Targets = append(m.Targets, l+": "+t)
+				}
+			}
+		}
+		res.Terms = append(res.Terms, m)
+	}
+	return res
+}
+
+// targets returns the translations mandated for locale, falling back from
+// "pt_BR" to "pt" and the other way round.
+func (t GlossaryTerm) targets(locale string) []string {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		return nil
+	}
+	if v, ok := t.Targets[locale]; ok {
+		return v
+	}
+	lang, _, _ := strings.Cut(locale, "_")
+	if v, ok := t.Targets[lang]; ok {
+		return v
+	}
+	for _, l := range sortedKeys(t.Targets) {
+		if strings.HasPrefix(l, lang+"_") {
+			return t.Targets[l]
+		}
+	}
+	return nil
+}
+
+// containsTerm reports whether term occurs in text as a whole word, case
+// insensitively. With prefixOnly the match may continue into a longer word,
+// so inflected forms ("presupuestos") satisfy the base term.
+func containsTerm(text, term string, prefixOnly bool) bool {
+	if term == "" {
+		return false
+	}
+	lowerText, lowerTerm := strings.ToLower(text), strings.ToLower(term)
+	for from := 0; from <= len(lowerText); {
+		i := strings.Index(lowerText[from:], lowerTerm)
+		if i < 0 {
+			return false
+		}
+		start := from + i
+		end := start + len(lowerTerm)
+		before, _ := utf8.DecodeLastRuneInString(lowerText[:start])
+		after, _ := utf8.DecodeRuneInString(lowerText[end:])
+		if !isWordRune(before) && (prefixOnly || !isWordRune(after)) {
+			return true
+		}
+		from = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parseGlossaryCSV(content string) (*Glossary, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse glossary csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("glossary csv has no header row")
+	}
+
+	sourceCol, dntCol, noteCol := -1, -1, -1
+	locales := make(map[int]string)
+	for i, name := range rows[0] {
+		switch key := strings.ToLower(strings.TrimSpace(name)); key {
+		case "source", "term":
+			sourceCol = i
+		case "dnt", "do_not_translate", "do-not-translate":
+			dntCol = i
+		case "note", "notes", "comment":
+			noteCol = i
+		default:
+			if key != "" {
+				locales[i] = NormalizeLocale(name)
+			}
+		}
+	}
+	if sourceCol < 0 {
+		return nil, errors.New("glossary csv needs a source column")
+	}
+
+	g := &Glossary{}
+	for _, row := range rows[1:] {
+		cell := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		term := GlossaryTerm{Source: cell(sourceCol), Note: cell(noteCol), Targets: make(map[string][]string)}
+		if term.Source == "" {
+			continue
+		}
+		switch strings.ToLower(cell(dntCol)) {
+		case "1", "true", "yes", "y", "x":
+			term.DoNotTranslate = true
+		}
+		for col, locale := range locales {
+			for _, t := range strings.Split(cell(col), "|") {
+				if t = strings.TrimSpace(t); t != "" {
+					term.Targets[locale] = append(term.Targets[locale], t)
+				}
+			}
+		}
+		g.Terms = append(g.Terms, term)
+	}
+	return g, nil
+}
+
+// tbxEntry collects the terms of one termEntry or conceptEntry.
+type tbxEntry struct {
+	terms map[string][]string // locale -> terms
+	dnt   bool
+	note  string
+}
+
+func parseGlossaryTBX(content string) (*Glossary, error) {
+	dec := xml.NewDecoder(strings.NewReader(content))
+	g := &Glossary{}
+
+	srcLang := ""
+	var entry *tbxEntry
+	lang := ""
+	var field string // "term", "note", or a DNT data category being read
+	var text strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse glossary tbx: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "martif", "tbx":
+				srcLang = xmlLang(t)
+			case "termEntry", "conceptEntry":
+				entry = &tbxEntry{terms: make(map[string][]string)}
+			case "langSet", "langSec":
+				lang = xmlLang(t)
+			case "term":
+				field = "term"
+				text.Reset()
+			case "descrip", "termNote", "admin", "note":
+				typ := attr(t, "type")
+				switch {
+				case typ == "translatability" || typ == "doNotTranslate":
+					field = typ
+				case t.Name.Local == "note" || typ == "definition" || typ == "context":
+					field = "note"
+				default:
+					field = ""
+				}
+				text.Reset()
+			}
+		case xml.CharData:
+			if field != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch t.Name.Local {
+			case "term":
+				if entry != nil && value != "" {
+					l := NormalizeLocale(lang)
+					entry.terms[l] = append(entry.terms[l], value)
+				}
+			case "descrip", "termNote", "admin", "note":
+				if entry != nil {
+					switch field {
+					case "translatability":
+						entry.dnt = entry.dnt || strings.EqualFold(value, "nonTranslatable")
+					case "doNotTranslate":
+						switch strings.ToLower(value) {
+						case "1", "true", "yes":
+							entry.dnt = true
+						}
+					case "note":
+						if entry.note == "" {
+							entry.note = value
+						}
+					}
+				}
+			case "termEntry", "conceptEntry":
+				if entry != nil {
+					if term, ok := entry.term(srcLang); ok {
+						g.Terms = append(g.Terms, term)
+					}
+				}
+				entry = nil
+			}
+			if t.Name.Local != "langSet" && t.Name.Local != "langSec" {
+				field = ""
+			}
+		}
+	}
+	return g, nil
+}
+
+// term builds a glossary term, taking the source from srcLang.
+func (e *tbxEntry) term(srcLang string) (GlossaryTerm, bool) {
+	if srcLang == "" {
+		srcLang = "en"
+	}
+	term := GlossaryTerm{DoNotTranslate: e.dnt, Note: e.note, Targets: make(map[string][]string)}
+	for _, l := range sortedKeys(e.terms) {
+		if term.Source == "" && sameLanguage(l, srcLang) {
+			term.Source = e.terms[l][0]
+			continue
+		}
+		term.Targets[l] = e.terms[l]
+	}
+	return term, term.Source != ""
+}
+
+func xmlLang(t xml.StartElement) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == "lang" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
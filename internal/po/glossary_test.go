@@ -0,0 +1,185 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const glossaryCSV = `source,es_ES,fr,dnt,note
+Pinterest,,,yes,Brand name
+quote,presupuesto|cotización,devis,,Price estimate
+made to measure,a medida,sur mesure,,
+`
+
+const glossaryTBX = `<?xml version="1.0"?>
+<martif type="TBX" xml:lang="en">
+  <text><body>
+    <termEntry id="t1">
+      <descrip type="definition">Price estimate</descrip>
+      <langSet xml:lang="en"><tig><term>quote</term></tig></langSet>
+      <langSet xml:lang="es-ES"><tig><term>presupuesto</term></tig></langSet>
+    </termEntry>
+    <termEntry id="t2">
+      <langSet xml:lang="en"><tig><term>Pinterest</term><termNote type="translatability">nonTranslatable</termNote></tig></langSet>
+    </termEntry>
+  </body></text>
+</martif>`
+
+const glossaryPO = `msgid ""
+msgstr ""
+"Language: es_ES\n"
+
+msgid "Request a quote"
+msgstr "Solicita presupuestos"
+
+msgid "Ask for a quote"
+msgstr "Pide un precio"
+
+msgid "Share on Pinterest"
+msgstr "Compartir en Pinterés"
+
+msgid "Made to measure furniture"
+msgstr "Muebles a medida"
+`
+
+func TestParseGlossaryFormats(t *testing.T) {
+	csvGlossary, err := ParseGlossary(glossaryCSV, "")
+	if err != nil {
+		t.Fatalf("csv parse returned error: %v", err)
+	}
+	if len(csvGlossary.Terms) != 3 || !csvGlossary.Terms[0].DoNotTranslate {
+		t.Fatalf("unexpected csv glossary: %+v", csvGlossary)
+	}
+	if got := csvGlossary.Terms[1].Targets["es_ES"]; len(got) != 2 || got[1] != "cotización" {
+		t.Fatalf("unexpected alternatives: %q", got)
+	}
+
+	tbxGlossary, err := ParseGlossary(glossaryTBX, "")
+	if err != nil {
+		t.Fatalf("tbx parse returned error: %v", err)
+	}
+	if len(tbxGlossary.Terms) != 2 {
+		t.Fatalf("unexpected tbx glossary: %+v", tbxGlossary)
+	}
+	quote := tbxGlossary.Terms[0]
+	if quote.Source != "quote" || quote.Targets["es_ES"][0] != "presupuesto" || quote.Note != "Price estimate" {
+		t.Fatalf("unexpected tbx term: %+v", quote)
+	}
+	if !tbxGlossary.Terms[1].DoNotTranslate {
+		t.Fatalf("expected nonTranslatable term to be do-not-translate")
+	}
+}
+
+func TestCheckGlossary(t *testing.T) {
+	g, err := ParseGlossary(glossaryCSV, "csv")
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	warnings, err := NewService().CheckGlossary(context.Background(), glossaryPO, g)
+	if err != nil {
+		t.Fatalf("check returned error: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %q", warnings)
+	}
+	joined := strings.Join(warnings, "\n")
+	if !strings.Contains(joined, `term "quote" should be translated as "presupuesto | cotización" in entry: Ask for a quote`) {
+		t.Fatalf("missing target term warning:\n%s", joined)
+	}
+	if !strings.Contains(joined, `do-not-translate term "Pinterest" was translated in entry: Share on Pinterest`) {
+		t.Fatalf("missing do-not-translate warning:\n%s", joined)
+	}
+}
+
+func TestCheckGlossaryDoNotTranslateForms(t *testing.T) {
+	g, err := ParseGlossary(glossaryCSV, "csv")
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	const po = `msgid ""
+msgstr ""
+"Language: es_ES\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "PINTEREST BOARDS"
+msgstr "TABLEROS DE PINTEREST"
+
+msgid "share on pinterest"
+msgstr "compartir en Pinterest"
+
+msgid "%d Pinterest pin"
+msgid_plural "%d Pinterest pins"
+msgstr[0] "%d pin de Pinterest"
+msgstr[1] "%d pines de Pinterés"
+`
+	warnings, err := NewService().CheckGlossary(context.Background(), po, g)
+	if err != nil {
+		t.Fatalf("check returned error: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0] != `glossary: do-not-translate term "Pinterest" was translated in entry: %d Pinterest pin` {
+		t.Fatalf("expected only the translated plural form reported, got %q", warnings)
+	}
+}
+
+func TestCheckGlossaryTermPluralForms(t *testing.T) {
+	g, err := ParseGlossary(glossaryCSV, "csv")
+	if err != nil {
+		t.Fatalf("parse returned error: %v", err)
+	}
+	const po = `msgid ""
+msgstr ""
+"Language: es_ES\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "%d quote request"
+msgid_plural "%d quote requests"
+msgstr[0] "%d solicitud de presupuesto"
+msgstr[1] "%d solicitudes de precio"
+
+msgid "%d new quote request"
+msgid_plural "%d new quote requests"
+msgstr[0] "%d solicitud de presupuesto nueva"
+msgstr[1] "%d solicitudes de presupuestos nuevas"
+`
+	warnings, err := NewService().CheckGlossary(context.Background(), po, g)
+	if err != nil {
+		t.Fatalf("check returned error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasSuffix(warnings[0], "in entry: %d quote request") {
+		t.Fatalf("expected the wrong plural form reported once, got %q", warnings)
+	}
+}
+
+func TestGlossaryLookupAndProject(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "scp-pinterest.csv"), []byte(glossaryCSV), 0o600); err != nil {
+		t.Fatalf("cannot write glossary: %v", err)
+	}
+	svc := NewService(WithGlossaryDir(dir))
+
+	g, err := svc.ProjectGlossary("scp-pinterest")
+	if err != nil {
+		t.Fatalf("project glossary returned error: %v", err)
+	}
+	if _, err := svc.ProjectGlossary("../scp-pinterest"); err == nil || errors.Is(err, ErrGlossaryNotFound) {
+		t.Fatalf("expected error for a project name with a path, got %v", err)
+	}
+	if _, err := svc.ProjectGlossary("other"); !errors.Is(err, ErrGlossaryNotFound) {
+		t.Fatalf("expected ErrGlossaryNotFound for a project without glossary, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.tbx"), []byte("<martif><text>"), 0o600); err != nil {
+		t.Fatalf("cannot write glossary: %v", err)
+	}
+	if _, err := svc.ProjectGlossary("broken"); err == nil || errors.Is(err, ErrGlossaryNotFound) {
+		t.Fatalf("expected a parse error for a malformed glossary, got %v", err)
+	}
+
+	res := svc.GlossaryLookup(context.Background(), g, "Request a quote made to measure", "fr_FR")
+	if len(res.Terms) != 2 || res.Terms[0].Targets[0] != "devis" || res.Terms[1].Targets[0] != "sur mesure" {
+		t.Fatalf("unexpected lookup: %+v", res)
+	}
+}
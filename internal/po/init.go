@@ -0,0 +1,133 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InitOptions controls how Init turns a template into a locale catalog.
+type InitOptions struct {
+	// English pre-fills every msgstr with its msgid (msgen).
+	English bool
+	// PluralForms overrides the built-in Plural-Forms rule for the locale.
+	PluralForms    string
+	LanguageTeam   string
+	LastTranslator string
+}
+
+// InitResult is the output of Init.
+type InitResult struct {
+	Content     string `json:"po_content"`
+	Locale      string `json:"locale"`
+	PluralForms string `json:"plural_forms"`
+	Messages    int    `json:"messages"`
+}
+
+// Init creates a new locale catalog from POT content, like msginit: the
+// header is filled for the locale and plural entries get one empty msgstr
+// per plural form.
+func (s *Service) Init(ctx context.Context, potContent, locale string, opts InitOptions) (*InitResult, error) {
+	locale = NormalizeLocale(locale)
+	if locale == "" {
+		return nil, errors.New("locale is required")
+	}
+
+	pluralForms := strings.TrimSpace(opts.PluralForms)
+	if pluralForms == "" {
+		rule, ok := PluralFormsFor(locale)
+		if !ok {
+			return nil, fmt.Errorf("no plural rule known for locale %q, pass plural_forms explicitly", locale)
+		}
+		pluralForms = rule
+	}
+	nplurals := NPlurals(pluralForms)
+	if nplurals < 1 {
+		return nil, fmt.Errorf("invalid Plural-Forms %q", pluralForms)
+	}
+
+	cat, err := ParseCatalog(potContent)
+	if err != nil {
+		return nil, err
+	}
+
+	langName := LanguageName(locale)
+	team := opts.LanguageTeam
+	if team == "" {
+		team = langName
+		if i := strings.Index(team, " ("); i > 0 {
+			team = team[:i]
+		}
+	}
+	if team == "" {
+		team = locale
+	}
+
+	cat.SetHeaderField("PO-Revision-Date", time.Now().Format("2006-01-02 15:04-0700"))
+	if opts.LastTranslator != "" {
+		cat.SetHeaderField("Last-Translator", opts.LastTranslator)
+	}
+	cat.SetHeaderField("Language-Team", team)
+	cat.SetHeaderField("Language", locale)
+	cat.SetHeaderField("MIME-Version", "1.0")
+	cat.SetHeaderField("Content-Type", "text/plain; charset=UTF-8")
+	cat.SetHeaderField("Content-Transfer-Encoding", "8bit")
+	cat.SetHeaderField("Plural-Forms", pluralForms)
+
+	hdr := cat.Header()
+	hdr.RemoveFlag("fuzzy")
+	titleHeaderComment(hdr, langName, cat.HeaderField("Project-Id-Version"))
+
+	res := &InitResult{Locale: locale, PluralForms: pluralForms}
+	for _, e := range cat.Messages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if e.Obsolete {
+			continue
+		}
+		res.Messages++
+		if e.IsPlural() {
+			forms := make([]string, nplurals)
+			if opts.English {
+				for i := range forms {
+					forms[i] = e.MsgidPlural
+				}
+				forms[0] = e.Msgid
+			}
+			e.Msgstr = forms
+			continue
+		}
+		e.Msgstr = []string{""}
+		if opts.English {
+			e.Msgstr[0] = e.Msgid
+		}
+	}
+
+	res.Content = cat.String()
+	return res, nil
+}
+
+// titleHeaderComment replaces the template title comment with a
+// "<Language> translation for <project>" line, as msginit does.
+func titleHeaderComment(hdr *Entry, langName, project string) {
+	if langName == "" {
+		return
+	}
+	if project == "" || strings.Contains(project, "PACKAGE") {
+		project = "this package"
+	}
+	title := fmt.Sprintf("%s translation for %s", langName, project)
+	for i, c := range hdr.Comments {
+		if c == "SOME DESCRIPTIVE TITLE." {
+			hdr.Comments[i] = title
+			return
+		}
+		if strings.Contains(c, "translation for") {
+			return
+		}
+	}
+	hdr.Comments = append([]string{title}, hdr.Comments...)
+}
@@ -0,0 +1,89 @@
+package po
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInitFromPOT(t *testing.T) {
+	pot, err := os.ReadFile("../../test/scp-pinterest.pot")
+	if err != nil {
+		t.Fatalf("cannot read fixture: %v", err)
+	}
+
+	svc := NewService()
+	res, err := svc.Init(context.Background(), string(pot), "it-it", InitOptions{})
+	if err != nil {
+		t.Fatalf("init returned error: %v", err)
+	}
+	if res.Locale != "it_IT" || res.PluralForms != "nplurals=2; plural=(n != 1);" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	cat, err := ParseCatalog(res.Content)
+	if err != nil {
+		t.Fatalf("output does not parse: %v", err)
+	}
+	for field, want := range map[string]string{
+		"Language":      "it_IT",
+		"Language-Team": "Italian",
+		"Plural-Forms":  res.PluralForms,
+		"Content-Type":  "text/plain; charset=UTF-8",
+	} {
+		if got := cat.HeaderField(field); got != want {
+			t.Fatalf("header %s: expected %q, got %q", field, want, got)
+		}
+	}
+	if cat.HeaderField("PO-Revision-Date") == "" {
+		t.Fatalf("PO-Revision-Date not set")
+	}
+	if cat.Header().Comments[0] != "Italian (Italy) translation for SCP Pinterest Plugin" {
+		t.Fatalf("unexpected title comment: %q", cat.Header().Comments[0])
+	}
+
+	summary, err := svc.Summarize(context.Background(), res.Content)
+	if err != nil {
+		t.Fatalf("summarize returned error: %v", err)
+	}
+	if summary.Translated != 0 || summary.Total != res.Messages {
+		t.Fatalf("expected an empty catalog, got %+v", summary)
+	}
+}
+
+func TestInitExpandsPluralsAndEnglish(t *testing.T) {
+	pot := `msgid ""
+msgstr ""
+"Project-Id-Version: PACKAGE VERSION\n"
+"Language: \n"
+"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"
+
+msgid "day"
+msgid_plural "days"
+msgstr[0] ""
+msgstr[1] ""
+`
+	svc := NewService()
+	res, err := svc.Init(context.Background(), pot, "ru_RU", InitOptions{})
+	if err != nil {
+		t.Fatalf("init returned error: %v", err)
+	}
+	if !strings.Contains(res.Content, "msgstr[2] \"\"") {
+		t.Fatalf("expected three plural forms:\n%s", res.Content)
+	}
+
+	res, err = svc.Init(context.Background(), pot, "en_US", InitOptions{English: true})
+	if err != nil {
+		t.Fatalf("init returned error: %v", err)
+	}
+	cat, _ := ParseCatalog(res.Content)
+	day := cat.Find(nil, "day")
+	if len(day.Msgstr) != 2 || day.Msgstr[0] != "day" || day.Msgstr[1] != "days" {
+		t.Fatalf("expected msgen forms, got %q", day.Msgstr)
+	}
+
+	if _, err := svc.Init(context.Background(), pot, "xx", InitOptions{}); err == nil {
+		t.Fatalf("expected error for unknown locale without plural_forms")
+	}
+}
@@ -0,0 +1,198 @@
+package po
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ListOptions controls the paging of ListEntries.
+type ListOptions struct {
+	// Cursor resumes after the previous page; empty starts at the beginning.
+	Cursor string
+	// Limit caps the entries per page; defaults to 100.
+	Limit int
+	// MaxTokens is the approximate token budget of a page; defaults to 4000.
+	// A page always holds at least one entry.
+	MaxTokens int
+}
+
+// ListedEntry is the JSON view of one catalog entry.
+type ListedEntry struct {
+	ID                string   `json:"id"`
+	State             string   `json:"state"`
+	Msgctxt           *string  `json:"msgctxt,omitempty"`
+	Msgid             string   `json:"msgid"`
+	MsgidPlural       string   `json:"msgid_plural,omitempty"`
+	Msgstr            []string `json:"msgstr"`
+	Flags             []string `json:"flags,omitempty"`
+	References        []string `json:"references,omitempty"`
+	Comments          []string `json:"comments,omitempty"`
+	ExtractedComments []string `json:"extracted_comments,omitempty"`
+	Previous          []string `json:"previous,omitempty"`
+}
+
+// ListResult is one page of ListEntries.
+type ListResult struct {
+	Hash       string        `json:"hash"`
+	Matched    int           `json:"matched"`
+	Total      int           `json:"total"`
+	Entries    []ListedEntry `json:"entries"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ListEntries returns the entries selected by filter one page at a time. The
+// cursor is bound to the catalog content and the filter: paging through a
+// catalog that changed in between, or with another filter, fails instead of
+// skipping or repeating entries. Its offset counts matched entries, so Limit
+// and MaxTokens may change from one page to the next.
+func (s *Service) ListEntries(ctx context.Context, poContent string, filter EntryFilter, opts ListOptions) (*ListResult, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = 4000
+	}
+
+	hash := ContentHash(poContent)
+	filterHash := filter.hash()
+	offset := 0
+	if opts.Cursor != "" {
+		var err error
+		offset, err = decodeCursor(opts.Cursor, hash, filterHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	match, err := filter.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ListResult{Hash: hash, Entries: []ListedEntry{}}
+	var selected []*Entry
+	for _, e := range cat.Messages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res.Total++
+		if match(e) {
+			selected = append(selected, e)
+		}
+	}
+	res.Matched = len(selected)
+	if offset > len(selected) {
+		return nil, errors.New("invalid cursor")
+	}
+
+	tokens := 0
+	next := offset
+	for ; next < len(selected) && len(res.Entries) < opts.Limit; next++ {
+		le := listedEntry(selected[next])
+		data, _ := json.Marshal(le)
+		cost := estimateTokens(string(data))
+		if len(res.Entries) > 0 && tokens+cost > opts.MaxTokens {
+			break
+		}
+		tokens += cost
+		res.Entries = append(res.Entries, le)
+	}
+	if next < len(selected) {
+		res.NextCursor = encodeCursor(hash, filterHash, next)
+	}
+	return res, nil
+}
+
+// Entry returns the entry of a catalog with the given stable id.
+func (s *Service) Entry(ctx context.Context, poContent, id string) (*ListedEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range cat.Messages() {
+		if e.ID() == id {
+			le := listedEntry(e)
+			return &le, nil
+		}
+	}
+	return nil, fmt.Errorf("no entry with id %s", id)
+}
+
+func listedEntry(e *Entry) ListedEntry {
+	le := ListedEntry{
+		ID:                e.ID(),
+		State:             e.State(),
+		Msgid:             e.Msgid,
+		MsgidPlural:       e.MsgidPlural,
+		Msgstr:            e.Msgstr,
+		Flags:             e.Flags,
+		References:        e.References,
+		Comments:          e.Comments,
+		ExtractedComments: e.ExtractedComments,
+		Previous:          e.Previous,
+	}
+	if e.HasContext {
+		ctx := e.Msgctxt
+		le.Msgctxt = &ctx
+	}
+	if le.Msgstr == nil {
+		le.Msgstr = []string{}
+	}
+	return le
+}
+
+// estimateTokens approximates the token count of text at four bytes a token.
+func estimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// Cursors are opaque to clients: base64 of
+// "<content hash>:<filter hash>:<offset>".
+
+func encodeCursor(hash, filterHash string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(hash + ":" + filterHash + ":" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor, hash, filterHash string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return 0, errors.New("invalid cursor")
+	}
+	offset, err := strconv.Atoi(parts[2])
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	if parts[0] != hash {
+		return 0, fmt.Errorf("catalog changed since the cursor was issued (hash %s, cursor for %s); list again from the start", hash, parts[0])
+	}
+	if parts[1] != filterHash {
+		return 0, errors.New("the cursor was issued for other filters; pass the filters of the first page or list again from the start")
+	}
+	return offset, nil
+}
+
+// hash identifies the entries f selects, for binding cursors to it; the
+// order of states and flags does not matter.
+func (f EntryFilter) hash() string {
+	f.States = slices.Sorted(slices.Values(f.States))
+	f.Flags = slices.Sorted(slices.Values(f.Flags))
+	data, _ := json.Marshal(f)
+	return ContentHash(string(data))
+}
@@ -0,0 +1,142 @@
+package po
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestListEntriesPages(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+
+	var ids []string
+	cursor := ""
+	for page := 0; ; page++ {
+		res, err := svc.ListEntries(ctx, annotatedPO, EntryFilter{}, ListOptions{Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("list returned error: %v", err)
+		}
+		if res.Total != 5 || res.Matched != 5 || res.Hash != ContentHash(annotatedPO) {
+			t.Fatalf("unexpected counts: %+v", res)
+		}
+		for _, e := range res.Entries {
+			ids = append(ids, e.ID)
+		}
+		if res.NextCursor == "" {
+			break
+		}
+		if page > 3 {
+			t.Fatalf("paging does not terminate")
+		}
+		cursor = res.NextCursor
+	}
+	if len(ids) != 5 {
+		t.Fatalf("expected 5 entries over all pages, got %d", len(ids))
+	}
+
+	cat, _ := ParseCatalog(annotatedPO)
+	menu := "menu"
+	if ids[3] != cat.Find(&menu, "File").ID() {
+		t.Fatalf("entry ids do not match catalog order: %v", ids)
+	}
+}
+
+func TestListEntriesFiltersAndDetails(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+
+	res, err := svc.ListEntries(ctx, annotatedPO, EntryFilter{MsgstrRegex: "^Arch"}, ListOptions{})
+	if err != nil {
+		t.Fatalf("list returned error: %v", err)
+	}
+	if res.Matched != 1 || len(res.Entries) != 1 {
+		t.Fatalf("expected the plural entry only, got %+v", res)
+	}
+	e := res.Entries[0]
+	if e.Msgctxt == nil || *e.Msgctxt != "menu" || e.MsgidPlural != "Files" || len(e.Msgstr) != 2 {
+		t.Fatalf("unexpected entry details: %+v", e)
+	}
+
+	res, err = svc.ListEntries(ctx, annotatedPO, EntryFilter{Reference: "src/admin/"}, ListOptions{})
+	if err != nil {
+		t.Fatalf("list returned error: %v", err)
+	}
+	if res.Matched != 2 || res.Entries[0].ExtractedComments[0] != "Shown on the dashboard" || res.Entries[1].Flags[0] != "fuzzy" {
+		t.Fatalf("unexpected reference filter result: %+v", res)
+	}
+}
+
+func TestListEntriesTokenBudgetAndStaleCursor(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+
+	res, err := svc.ListEntries(ctx, annotatedPO, EntryFilter{}, ListOptions{MaxTokens: 1})
+	if err != nil {
+		t.Fatalf("list returned error: %v", err)
+	}
+	if len(res.Entries) != 1 || res.NextCursor == "" {
+		t.Fatalf("expected a single entry per page under a tiny budget, got %+v", res)
+	}
+
+	changed := strings.Replace(annotatedPO, `msgstr "Hola %s"`, `msgstr "¡Hola %s!"`, 1)
+	if _, err := svc.ListEntries(ctx, changed, EntryFilter{}, ListOptions{Cursor: res.NextCursor}); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Fatalf("expected stale cursor error, got %v", err)
+	}
+	if _, err := svc.ListEntries(ctx, annotatedPO, EntryFilter{}, ListOptions{Cursor: "!!"}); err == nil {
+		t.Fatalf("expected invalid cursor error")
+	}
+}
+
+func TestListEntriesCursorBoundToFilter(t *testing.T) {
+	svc := NewService()
+	ctx := context.Background()
+	filter := EntryFilter{States: []string{StateTranslated, StateFuzzy}}
+
+	res, err := svc.ListEntries(ctx, annotatedPO, filter, ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("list returned error: %v", err)
+	}
+	if len(res.Entries) != 1 || res.Entries[0].Msgid != "Hello %s" || res.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", res)
+	}
+
+	for _, other := range []EntryFilter{
+		{},
+		{States: []string{StateTranslated}},
+		{States: []string{StateTranslated, StateFuzzy}, Invert: true},
+		{States: []string{StateTranslated, StateFuzzy}, MsgidRegex: "."},
+	} {
+		if _, err := svc.ListEntries(ctx, annotatedPO, other, ListOptions{Cursor: res.NextCursor}); err == nil || !strings.Contains(err.Error(), "other filters") {
+			t.Fatalf("cursor accepted with filter %+v: %v", other, err)
+		}
+	}
+
+	// The order of states does not matter, and the limit may change.
+	filter.States = []string{StateFuzzy, StateTranslated}
+	next, err := svc.ListEntries(ctx, annotatedPO, filter, ListOptions{Cursor: res.NextCursor, Limit: 10})
+	if err != nil {
+		t.Fatalf("list with the same filter returned error: %v", err)
+	}
+	if len(next.Entries) != 2 || next.Entries[0].Msgid != "Save" || next.Entries[1].Msgid != "File" || next.NextCursor != "" {
+		t.Fatalf("unexpected second page: %+v", next)
+	}
+}
+
+func TestEntryByID(t *testing.T) {
+	svc := NewService()
+	cat, _ := ParseCatalog(annotatedPO)
+	menu := "menu"
+	id := cat.Find(&menu, "File").ID()
+
+	e, err := svc.Entry(context.Background(), annotatedPO, id)
+	if err != nil {
+		t.Fatalf("entry returned error: %v", err)
+	}
+	if e.ID != id || e.Msgctxt == nil || *e.Msgctxt != "menu" || len(e.Msgstr) != 2 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if _, err := svc.Entry(context.Background(), annotatedPO, "000000000000"); err == nil {
+		t.Fatalf("expected error for an unknown id")
+	}
+}
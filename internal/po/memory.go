@@ -0,0 +1,350 @@
+package po
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryFileVersion is bumped when the on-disk layout changes.
+const memoryFileVersion = 1
+
+// MemoryEntry is one unit of the translation memory, keyed by locale,
+// context and source text.
+type MemoryEntry struct {
+	Locale       string    `json:"locale"`
+	Context      string    `json:"context,omitempty"`
+	Source       string    `json:"source"`
+	SourcePlural string    `json:"source_plural,omitempty"`
+	Target       []string  `json:"target"` // msgstr, or every msgstr[n] for plurals
+	Note         string    `json:"note,omitempty"`
+	Origin       string    `json:"origin,omitempty"` // project the unit came from
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+}
+
+// MemoryMatch is a translation memory hit for a source string.
+type MemoryMatch struct {
+	Entry MemoryEntry `json:"entry"`
+	Score float64     `json:"score"`
+	Exact bool        `json:"exact"`
+}
+
+// Memory is a translation memory persisted as a JSON file. It is safe for
+// concurrent use. A Memory with an empty path lives in memory only.
+type Memory struct {
+	path     string
+	readOnly bool
+	mu       sync.RWMutex
+	entries  map[string]*MemoryEntry
+}
+
+// ErrMemoryReadOnly is returned when adding to a memory opened with
+// OpenMemoryReadOnly.
+var ErrMemoryReadOnly = errors.New("translation memory is read-only")
+
+type memoryFile struct {
+	Version int           `json:"version"`
+	Entries []MemoryEntry `json:"entries"`
+}
+
+// OpenMemory loads the translation memory stored at path, starting empty if
+// the file does not exist yet.
+func OpenMemory(path string) (*Memory, error) {
+	m := &Memory{path: path, entries: make(map[string]*MemoryEntry)}
+	if path == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read translation memory: %w", err)
+	}
+
+	var file memoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse translation memory %s: %w", path, err)
+	}
+	if file.Version > memoryFileVersion {
+		return nil, fmt.Errorf("translation memory %s has unsupported version %d", path, file.Version)
+	}
+	for i := range file.Entries {
+		e := file.Entries[i]
+		m.entries[memoryKey(e.Locale, e.Context, e.Source)] = &e
+	}
+	return m, nil
+}
+
+// OpenMemoryReadOnly loads the translation memory stored at path for lookups
+// only: Add fails with ErrMemoryReadOnly and the file is never written.
+func OpenMemoryReadOnly(path string) (*Memory, error) {
+	m, err := OpenMemory(path)
+	if err != nil {
+		return nil, err
+	}
+	m.readOnly = true
+	return m, nil
+}
+
+// Path returns the file backing the memory, or "" for an in-memory one.
+func (m *Memory) Path() string {
+	return m.path
+}
+
+// Len returns the number of stored units.
+func (m *Memory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// Add inserts or updates units and persists the memory. Units without a
+// locale, source or complete target are skipped. It returns how many units
+// were stored.
+func (m *Memory) Add(entries ...MemoryEntry) (int, error) {
+	if m.readOnly {
+		return 0, ErrMemoryReadOnly
+	}
+	now := time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	added := 0
+	for _, e := range entries {
+		e.Locale = NormalizeLocale(e.Locale)
+		if e.Locale == "" || e.Source == "" || !complete(e.Target) {
+			continue
+		}
+		key := memoryKey(e.Locale, e.Context, e.Source)
+		if prev, ok := m.entries[key]; ok {
+			if e.Created.IsZero() || prev.Created.Before(e.Created) {
+				e.Created = prev.Created
+			}
+			if e.Note == "" {
+				e.Note = prev.Note
+			}
+		}
+		if e.Created.IsZero() {
+			e.Created = now
+		}
+		if e.Updated.IsZero() {
+			e.Updated = now
+		}
+		e.Target = append([]string(nil), e.Target...)
+		m.entries[key] = &e
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, m.save()
+}
+
+// AddCatalog stores every translated, non-fuzzy entry of a catalog under the
+// catalog's Language header.
+func (m *Memory) AddCatalog(cat *Catalog) (int, error) {
+	locale := cat.HeaderField("Language")
+	if locale == "" {
+		return 0, errors.New("catalog has no Language header")
+	}
+	origin := cat.HeaderField("Project-Id-Version")
+
+	var units []MemoryEntry
+	for _, e := range cat.Messages() {
+		if e.State() != StateTranslated {
+			continue
+		}
+		units = append(units, MemoryEntry{
+			Locale:       locale,
+			Context:      e.Msgctxt,
+			Source:       e.Msgid,
+			SourcePlural: e.MsgidPlural,
+			Target:       e.Msgstr,
+			Note:         strings.Join(e.ExtractedComments, "\n"),
+			Origin:       origin,
+		})
+	}
+	return m.Add(units...)
+}
+
+// Entries returns the units stored for a locale (every locale when empty),
+// sorted by source text.
+func (m *Memory) Entries(locale string) []MemoryEntry {
+	locale = NormalizeLocale(locale)
+
+	m.mu.RLock()
+	out := make([]MemoryEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		if locale == "" || e.Locale == locale {
+			out = append(out, *e)
+		}
+	}
+	m.mu.RUnlock()
+
+	sortMemoryEntries(out)
+	return out
+}
+
+// Lookup returns the best matches for a source string in a locale, best
+// first. Exact matches (same source and context) score 1; the same source
+// under another context scores 0.95; other units are scored by edit
+// distance and kept when they reach minScore.
+func (m *Memory) Lookup(locale, context, source string, minScore float64, limit int) []MemoryMatch {
+	locale = NormalizeLocale(locale)
+	src := []rune(source)
+
+	m.mu.RLock()
+	var matches []MemoryMatch
+	for _, e := range m.entries {
+		if e.Locale != locale {
+			continue
+		}
+		var score float64
+		switch {
+		case e.Source == source && e.Context == context:
+			score = 1
+		case e.Source == source:
+			score = 0.95
+		default:
+			score = similarity(src, []rune(e.Source), minScore)
+		}
+		if score >= minScore {
+			matches = append(matches, MemoryMatch{Entry: *e, Score: score, Exact: score == 1})
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Entry.Updated.After(matches[j].Entry.Updated)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// save writes the memory atomically. Callers hold m.mu.
+func (m *Memory) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	file := memoryFile{Version: memoryFileVersion, Entries: make([]MemoryEntry, 0, len(m.entries))}
+	for _, e := range m.entries {
+		file.Entries = append(file.Entries, *e)
+	}
+	sortMemoryEntries(file.Entries)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode translation memory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o750); err != nil {
+		return fmt.Errorf("create translation memory dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".memory-*.json")
+	if err != nil {
+		return fmt.Errorf("write translation memory: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write translation memory: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write translation memory: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write translation memory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write translation memory: %w", err)
+	}
+	return nil
+}
+
+func memoryKey(locale, context, source string) string {
+	return locale + "\x00" + context + "\x04" + source
+}
+
+func sortMemoryEntries(entries []MemoryEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Locale != b.Locale {
+			return a.Locale < b.Locale
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Context < b.Context
+	})
+}
+
+// complete reports whether every target form is non-empty.
+func complete(target []string) bool {
+	if len(target) == 0 {
+		return false
+	}
+	for _, t := range target {
+		if t == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity scores two strings between 0 and 1 from their Levenshtein
+// distance. Pairs whose length difference alone rules out minScore are
+// skipped without computing the distance.
+func similarity(a, b []rune, minScore float64) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	diff := len(a) - len(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	if 1-float64(diff)/float64(longest) < minScore {
+		return 0
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein computes the edit distance between two rune slices.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,131 @@
+package po
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryPersistsAndLooksUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tm", "memory.json")
+	mem, err := OpenMemory(path)
+	if err != nil {
+		t.Fatalf("open returned error: %v", err)
+	}
+
+	added, err := mem.Add(
+		MemoryEntry{Locale: "es-es", Source: "Request a quote", Target: []string{"Solicita presupuesto"}},
+		MemoryEntry{Locale: "es_ES", Context: "menu", Source: "Open", Target: []string{"Abrir"}},
+		MemoryEntry{Locale: "es_ES", Source: "Untranslated", Target: []string{""}},
+	)
+	if err != nil {
+		t.Fatalf("add returned error: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("expected 2 units stored, got %d", added)
+	}
+
+	reopened, err := OpenMemory(path)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	if reopened.Len() != 2 {
+		t.Fatalf("expected 2 persisted units, got %d", reopened.Len())
+	}
+	if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 || files[0].Name() != "memory.json" {
+		t.Fatalf("save left temporary files behind: %v", files)
+	}
+
+	matches := reopened.Lookup("es_ES", "", "Request a quote", 0.7, 3)
+	if len(matches) != 1 || !matches[0].Exact {
+		t.Fatalf("expected one exact match, got %+v", matches)
+	}
+	matches = reopened.Lookup("es_ES", "", "Request a quotes", 0.7, 3)
+	if len(matches) != 1 || matches[0].Exact || matches[0].Score < 0.9 {
+		t.Fatalf("expected one fuzzy match, got %+v", matches)
+	}
+	matches = reopened.Lookup("es_ES", "", "Open", 0.7, 3)
+	if len(matches) != 1 || matches[0].Exact || matches[0].Score != 0.95 {
+		t.Fatalf("expected a context mismatch score, got %+v", matches)
+	}
+	if got := reopened.Lookup("fr_FR", "", "Open", 0.7, 3); len(got) != 0 {
+		t.Fatalf("expected no match in another locale, got %+v", got)
+	}
+}
+
+func TestMemoryReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.json")
+	mem, err := OpenMemory(path)
+	if err != nil {
+		t.Fatalf("open returned error: %v", err)
+	}
+	if _, err := mem.Add(MemoryEntry{Locale: "es_ES", Source: "Open", Target: []string{"Abrir"}}); err != nil {
+		t.Fatalf("add returned error: %v", err)
+	}
+	before, _ := os.ReadFile(path)
+
+	ro, err := OpenMemoryReadOnly(path)
+	if err != nil {
+		t.Fatalf("open read-only returned error: %v", err)
+	}
+	if _, err := ro.Add(MemoryEntry{Locale: "es_ES", Source: "Close", Target: []string{"Cerrar"}}); !errors.Is(err, ErrMemoryReadOnly) {
+		t.Fatalf("expected ErrMemoryReadOnly, got %v", err)
+	}
+	if got := ro.Lookup("es_ES", "", "Open", 0.7, 3); len(got) != 1 {
+		t.Fatalf("expected the stored unit to be found, got %+v", got)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) || ro.Len() != 1 {
+		t.Fatalf("read-only memory changed: %d units", ro.Len())
+	}
+}
+
+func TestCompileFeedsMemoryAndSuggest(t *testing.T) {
+	mem, err := OpenMemory("")
+	if err != nil {
+		t.Fatalf("open returned error: %v", err)
+	}
+	svc := NewService(WithMemory(mem))
+	ctx := context.Background()
+
+	if _, err := svc.Compile(ctx, samplePO, CompileOptions{Return: "base64"}); err != nil {
+		t.Fatalf("compile returned error: %v", err)
+	}
+	if mem.Len() != 3 {
+		t.Fatalf("expected 3 units from compile, got %d", mem.Len())
+	}
+
+	untranslated := `msgid ""
+msgstr ""
+"Language: es\n"
+
+msgid "Hello"
+msgstr ""
+
+msgid "Hello!"
+msgstr ""
+
+msgid "Goodbye"
+msgstr ""
+`
+	res, err := svc.Suggest(ctx, untranslated, SuggestOptions{})
+	if err != nil {
+		t.Fatalf("suggest returned error: %v", err)
+	}
+	if res.Untranslated != 3 || len(res.Suggestions) != 2 {
+		t.Fatalf("unexpected suggestions: %+v", res)
+	}
+	if m := res.Suggestions[0].Matches[0]; !m.Exact || m.Entry.Target[0] != "Hola" {
+		t.Fatalf("unexpected exact match: %+v", m)
+	}
+	if m := res.Suggestions[1].Matches[0]; m.Exact || m.Entry.Source != "Hello" {
+		t.Fatalf("unexpected fuzzy match: %+v", m)
+	}
+}
+
+func TestSuggestWithoutMemory(t *testing.T) {
+	if _, err := NewService().Suggest(context.Background(), samplePO, SuggestOptions{}); err == nil {
+		t.Fatalf("expected error without a translation memory")
+	}
+}
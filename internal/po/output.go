@@ -0,0 +1,195 @@
+package po
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTempTTL is how long files written by Compile in "path" mode are
+// kept before they are swept.
+const DefaultTempTTL = time.Hour
+
+// WithTempTTL sets how long temporary .mo files live; they are also removed
+// by Close.
+func WithTempTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.temp.ttl = ttl
+	}
+}
+
+// OutputName returns the WordPress file name of a compiled or source catalog:
+// "{domain}-{locale}{ext}" for plugins and themes, or "{locale}{ext}" when the
+// domain is empty, as used for WordPress core and theme folders.
+func OutputName(domain, locale, ext string) string {
+	if domain == "" {
+		return locale + ext
+	}
+	return domain + "-" + locale + ext
+}
+
+// ExpandOutputPath fills the {locale} and {domain} placeholders of an output
+// path. The locale comes from the catalog Language header and the domain from
+// the domain argument, falling back to the X-Domain header.
+func ExpandOutputPath(pattern, poContent, domain string) (string, error) {
+	if !strings.Contains(pattern, "{") {
+		return pattern, nil
+	}
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return "", err
+	}
+	if domain == "" {
+		domain = cat.HeaderField("X-Domain")
+	}
+	locale := NormalizeLocale(cat.HeaderField("Language"))
+
+	if strings.Contains(pattern, "{locale}") && locale == "" {
+		return "", errors.New("output path uses {locale} but the catalog has no Language header")
+	}
+	if strings.Contains(pattern, "{domain}") && domain == "" {
+		return "", errors.New("output path uses {domain} but no domain was given and the catalog has no X-Domain header")
+	}
+	return strings.NewReplacer("{locale}", locale, "{domain}", domain).Replace(pattern), nil
+}
+
+// tempStore owns the directory of temporary outputs. It is created on first
+// use, swept of files older than the TTL, and removed by Close.
+type tempStore struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	dir    string
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+// write stores data in a new temp file named after pattern (see
+// os.CreateTemp) and returns its path.
+func (t *tempStore) write(pattern string, data []byte) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dir == "" {
+		dir, err := os.MkdirTemp("", "mcp-po-")
+		if err != nil {
+			return "", err
+		}
+		t.dir = dir
+		if t.ttl <= 0 {
+			t.ttl = DefaultTempTTL
+		}
+		t.ticker = time.NewTicker(max(t.ttl/2, time.Second))
+		t.done = make(chan struct{})
+		go t.sweepLoop(t.ticker, t.done)
+	}
+
+	f, err := os.CreateTemp(t.dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (t *tempStore) sweepLoop(ticker *time.Ticker, done chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			t.sweep(time.Now())
+		}
+	}
+}
+
+// sweep removes temp files last written before now minus the TTL.
+func (t *tempStore) sweep(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err == nil && now.Sub(info.ModTime()) > t.ttl {
+			_ = os.Remove(filepath.Join(t.dir, e.Name()))
+		}
+	}
+}
+
+// close removes the temp directory and everything in it.
+func (t *tempStore) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dir == "" {
+		return nil
+	}
+	t.ticker.Stop()
+	close(t.done)
+	err := os.RemoveAll(t.dir)
+	t.dir = ""
+	return err
+}
+
+// Close releases the resources owned by the Service, deleting the temporary
+// files written by Compile.
+func (s *Service) Close() error {
+	return s.temp.close()
+}
+
+// DefaultOutputName returns OutputName for a catalog, with the locale from
+// its Language header and the domain from the domain argument or X-Domain.
+func DefaultOutputName(poContent, domain, ext string) (string, error) {
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return "", err
+	}
+	if domain == "" {
+		domain = cat.HeaderField("X-Domain")
+	}
+	locale := NormalizeLocale(cat.HeaderField("Language"))
+	if locale == "" {
+		return "", errors.New("cannot name the output: the catalog has no Language header")
+	}
+	return OutputName(domain, locale, ext), nil
+}
+
+// CatalogName is the inverse of OutputName: it returns the domain and locale
+// of the catalog stored in file. The headers win (X-Domain, Language); the
+// file name fills what they leave out. The domain is empty for files named
+// after the locale alone.
+func CatalogName(poContent, file string) (domain, locale string) {
+	if cat, err := ParseCatalog(poContent); err == nil {
+		domain = cat.HeaderField("X-Domain")
+		locale = NormalizeLocale(cat.HeaderField("Language"))
+	}
+	stem := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	i := strings.LastIndexByte(stem, '-')
+	if locale == "" {
+		locale = NormalizeLocale(stem[i+1:])
+	}
+	if domain == "" {
+		switch {
+		case strings.EqualFold(stem, locale):
+		case len(stem) > len(locale) && strings.EqualFold(stem[len(stem)-len(locale)-1:], "-"+locale):
+			domain = stem[:len(stem)-len(locale)-1]
+		case i > 0:
+			domain = stem[:i]
+		}
+	}
+	return domain, locale
+}
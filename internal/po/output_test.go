@@ -0,0 +1,85 @@
+package po
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputNames(t *testing.T) {
+	if got := OutputName("scp-pinterest", "es_ES", ".mo"); got != "scp-pinterest-es_ES.mo" {
+		t.Fatalf("unexpected plugin name %q", got)
+	}
+	if got := OutputName("", "es_ES", ".mo"); got != "es_ES.mo" {
+		t.Fatalf("unexpected core name %q", got)
+	}
+
+	got, err := ExpandOutputPath("languages/{domain}-{locale}.mo", samplePO, "my-plugin")
+	if err != nil || got != "languages/my-plugin-es.mo" {
+		t.Fatalf("unexpected expansion %q, %v", got, err)
+	}
+	withDomain := strings.Replace(samplePO, `"Language: es\n"`, `"Language: es-es\n"`+"\n"+`"X-Domain: shop\n"`, 1)
+	if got, err := DefaultOutputName(withDomain, "", ".po"); err != nil || got != "shop-es_ES.po" {
+		t.Fatalf("unexpected default name %q, %v", got, err)
+	}
+	if _, err := ExpandOutputPath("{domain}-{locale}.mo", samplePO, ""); err == nil {
+		t.Fatalf("expected error for a missing domain")
+	}
+	if got, _ := ExpandOutputPath("plain.mo", "", ""); got != "plain.mo" {
+		t.Fatalf("paths without placeholders must be kept, got %q", got)
+	}
+}
+
+func TestCatalogName(t *testing.T) {
+	withDomain := strings.Replace(samplePO, `"Language: es\n"`, `"Language: es\n"`+"\n"+`"X-Domain: shop\n"`, 1)
+	for _, tc := range []struct {
+		content, file, domain, locale string
+	}{
+		{samplePO, "languages/scp-pinterest-es.po", "scp-pinterest", "es"},
+		{samplePO, "shop-es_ES.po", "shop", "es"},
+		{withDomain, "languages/es.po", "shop", "es"},
+		{"", "themes/pt_BR.po", "", "pt_BR"},
+		{"", "my-theme-de_DE.po", "my-theme", "de_DE"},
+	} {
+		domain, locale := CatalogName(tc.content, tc.file)
+		if domain != tc.domain || locale != tc.locale {
+			t.Fatalf("%s: expected %q/%q, got %q/%q", tc.file, tc.domain, tc.locale, domain, locale)
+		}
+	}
+}
+
+func TestCompilePathUsesOwnedTempDir(t *testing.T) {
+	svc := NewService(WithTempTTL(time.Minute))
+	res, err := svc.Compile(context.Background(), samplePO, CompileOptions{Return: "path"})
+	if err != nil {
+		t.Fatalf("compile returned error: %v", err)
+	}
+	dir := filepath.Dir(res.Path)
+	if !strings.HasPrefix(filepath.Base(dir), "mcp-po-") {
+		t.Fatalf("temp output outside the owned dir: %s", res.Path)
+	}
+
+	// Files past the TTL are swept; fresh ones are kept.
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(res.Path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	fresh, _ := svc.Compile(context.Background(), samplePO, CompileOptions{Return: "path"})
+	svc.temp.sweep(time.Now())
+	if _, err := os.Stat(res.Path); !os.IsNotExist(err) {
+		t.Fatalf("expired temp file was not swept")
+	}
+	if _, err := os.Stat(fresh.Path); err != nil {
+		t.Fatalf("fresh temp file was swept: %v", err)
+	}
+
+	if err := svc.Close(); err != nil {
+		t.Fatalf("close returned error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("temp dir survived Close")
+	}
+}
@@ -0,0 +1,121 @@
+package po
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderRe matches the parts of a message that must survive translation
+// untouched: printf directives (%s, %1$d, %.2f, %%), Python-style %(name)s,
+// {name} and {{name}} placeholders, HTML tags and HTML entities.
+var placeholderRe = regexp.MustCompile(
+	`%(?:\d+\$)?[-+0#']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspn%]` +
+		`|%\([A-Za-z_]\w*\)[-+0#]*\d*(?:\.\d+)?[diouxXeEfFgGcrs]` +
+		`|\{\{\s*[\w.-]+\s*\}\}|\{[\w.-]*\}` +
+		`|</?[A-Za-z][^<>]*>` +
+		`|&(?:[A-Za-z][A-Za-z0-9]*|#\d+|#[xX][0-9A-Fa-f]+);`)
+
+// textSegment is a run of translatable text or a single placeholder.
+type textSegment struct {
+	text        string
+	placeholder bool
+}
+
+// splitPlaceholders cuts s into alternating text and placeholder segments.
+func splitPlaceholders(s string) []textSegment {
+	var out []textSegment
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringIndex(s, -1) {
+		if loc[0] > last {
+			out = append(out, textSegment{text: s[last:loc[0]]})
+		}
+		out = append(out, textSegment{text: s[loc[0]:loc[1]], placeholder: true})
+		last = loc[1]
+	}
+	if last < len(s) {
+		out = append(out, textSegment{text: s[last:]})
+	}
+	return out
+}
+
+// placeholders returns the placeholders of s in order of appearance.
+func placeholders(s string) []string {
+	return placeholderRe.FindAllString(s, -1)
+}
+
+// Placeholders are swapped for numbered tokens before text goes to a
+// translation backend, so engines cannot alter them, and restored after.
+const (
+	tokenOpen  = "⟦"
+	tokenClose = "⟧"
+)
+
+var tokenRe = regexp.MustCompile(tokenOpen + `(\d+)` + tokenClose)
+
+// protectPlaceholders replaces every placeholder of s with a ⟦n⟧ token and
+// returns the protected text and the placeholders by token number.
+func protectPlaceholders(s string) (string, []string) {
+	var phs []string
+	var b strings.Builder
+	for _, seg := range splitPlaceholders(s) {
+		if !seg.placeholder {
+			b.WriteString(seg.text)
+			continue
+		}
+		b.WriteString(tokenOpen + strconv.Itoa(len(phs)) + tokenClose)
+		phs = append(phs, seg.text)
+	}
+	return b.String(), phs
+}
+
+// restorePlaceholders swaps ⟦n⟧ tokens back. Every token must appear exactly
+// once; otherwise the backend damaged the placeholders and an error is
+// returned.
+func restorePlaceholders(s string, phs []string) (string, error) {
+	seen := make([]bool, len(phs))
+	var err error
+	out := tokenRe.ReplaceAllStringFunc(s, func(tok string) string {
+		n, convErr := strconv.Atoi(tokenRe.FindStringSubmatch(tok)[1])
+		switch {
+		case convErr != nil || n >= len(phs):
+			err = fmt.Errorf("unknown placeholder token %s", tok)
+		case seen[n]:
+			err = fmt.Errorf("placeholder %s repeated", phs[n])
+		default:
+			seen[n] = true
+			return phs[n]
+		}
+		return tok
+	})
+	if err != nil {
+		return "", err
+	}
+	for i, ok := range seen {
+		if !ok {
+			return "", fmt.Errorf("placeholder %s missing", phs[i])
+		}
+	}
+	return out, nil
+}
+
+// protectLike protects a translation that already contains the literal
+// placeholders of its source, mapping each one to the source's token.
+func protectLike(s string, phs []string) string {
+	used := make([]bool, len(phs))
+	var b strings.Builder
+	for _, seg := range splitPlaceholders(s) {
+		if seg.placeholder {
+			for i, ph := range phs {
+				if !used[i] && ph == seg.text {
+					used[i] = true
+					seg.text = tokenOpen + strconv.Itoa(i) + tokenClose
+					break
+				}
+			}
+		}
+		b.WriteString(seg.text)
+	}
+	return b.String()
+}
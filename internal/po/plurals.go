@@ -0,0 +1,161 @@
+package po
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// pluralRules maps locales (or bare language codes) to their gettext
+// Plural-Forms header, following the values Poedit and GlotPress ship.
+var pluralRules = map[string]string{
+	// One form.
+	"ja": "nplurals=1; plural=0;",
+	"ko": "nplurals=1; plural=0;",
+	"zh": "nplurals=1; plural=0;",
+	"vi": "nplurals=1; plural=0;",
+	"th": "nplurals=1; plural=0;",
+	"id": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;",
+	"km": "nplurals=1; plural=0;",
+	"lo": "nplurals=1; plural=0;",
+	"my": "nplurals=1; plural=0;",
+
+	// Two forms, singular for n == 1.
+	"en": "nplurals=2; plural=(n != 1);",
+	"de": "nplurals=2; plural=(n != 1);",
+	"nl": "nplurals=2; plural=(n != 1);",
+	"sv": "nplurals=2; plural=(n != 1);",
+	"da": "nplurals=2; plural=(n != 1);",
+	"nb": "nplurals=2; plural=(n != 1);",
+	"nn": "nplurals=2; plural=(n != 1);",
+	"no": "nplurals=2; plural=(n != 1);",
+	"fi": "nplurals=2; plural=(n != 1);",
+	"et": "nplurals=2; plural=(n != 1);",
+	"el": "nplurals=2; plural=(n != 1);",
+	"he": "nplurals=2; plural=(n != 1);",
+	"it": "nplurals=2; plural=(n != 1);",
+	"es": "nplurals=2; plural=(n != 1);",
+	"pt": "nplurals=2; plural=(n != 1);",
+	"ca": "nplurals=2; plural=(n != 1);",
+	"eu": "nplurals=2; plural=(n != 1);",
+	"gl": "nplurals=2; plural=(n != 1);",
+	"hu": "nplurals=2; plural=(n != 1);",
+	"bg": "nplurals=2; plural=(n != 1);",
+	"af": "nplurals=2; plural=(n != 1);",
+	"sq": "nplurals=2; plural=(n != 1);",
+	"az": "nplurals=2; plural=(n != 1);",
+	"eo": "nplurals=2; plural=(n != 1);",
+	"hi": "nplurals=2; plural=(n != 1);",
+	"bn": "nplurals=2; plural=(n != 1);",
+	"ur": "nplurals=2; plural=(n != 1);",
+	"sw": "nplurals=2; plural=(n != 1);",
+
+	// Two forms, singular for n <= 1.
+	"fr":    "nplurals=2; plural=(n > 1);",
+	"pt_BR": "nplurals=2; plural=(n > 1);",
+	"tr":    "nplurals=2; plural=(n > 1);",
+	"fa":    "nplurals=2; plural=(n > 1);",
+	"oc":    "nplurals=2; plural=(n > 1);",
+	"fil":   "nplurals=2; plural=(n > 1);",
+	"hy":    "nplurals=2; plural=(n > 1);",
+
+	// Other two-form rules.
+	"is": "nplurals=2; plural=(n%10!=1 || n%100==11);",
+	"mk": "nplurals=2; plural=(n==1 || n%10==1 ? 0 : 1);",
+
+	// Three forms.
+	"ru": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"be": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"sr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"hr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"bs": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"pl": "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"cs": "nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;",
+	"sk": "nplurals=3; plural=(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2;",
+	"lt": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"lv": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+
+	// Four or more forms.
+	"sl": "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+	"cy": "nplurals=4; plural=(n==1) ? 0 : (n==2) ? 1 : (n != 8 && n != 11) ? 2 : 3;",
+	"ga": "nplurals=5; plural=(n==1 ? 0 : n==2 ? 1 : n<7 ? 2 : n<11 ? 3 : 4);",
+	"ar": "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+}
+
+var npluralsRe = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// PluralFormsFor returns the Plural-Forms header for a locale such as
+// "pt_BR" or "it", trying the full locale before the bare language.
+func PluralFormsFor(locale string) (string, bool) {
+	locale = NormalizeLocale(locale)
+	if rule, ok := pluralRules[locale]; ok {
+		return rule, true
+	}
+	lang, _, _ := strings.Cut(locale, "_")
+	lang, _, _ = strings.Cut(lang, "@")
+	rule, ok := pluralRules[lang]
+	return rule, ok
+}
+
+// NPlurals extracts nplurals from a Plural-Forms header, or 0 if absent.
+func NPlurals(pluralForms string) int {
+	m := npluralsRe.FindStringSubmatch(pluralForms)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// NormalizeLocale converts locale spellings such as "pt-br" or "PT_br" into
+// the gettext form "pt_BR", keeping any "@modifier".
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	locale, modifier, hasModifier := strings.Cut(locale, "@")
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '_' || r == '-' })
+	for i, p := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(p)
+		case len(p) == 4:
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		default:
+			parts[i] = strings.ToUpper(p)
+		}
+	}
+	out := strings.Join(parts, "_")
+	if hasModifier {
+		out += "@" + modifier
+	}
+	return out
+}
+
+// LanguageName returns the English display name of a locale, such as
+// "Portuguese (Brazil)", or "" when the locale is not recognised.
+func LanguageName(locale string) string {
+	locale, _, _ = strings.Cut(NormalizeLocale(locale), "@")
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return ""
+	}
+	base, conf := tag.Base()
+	if conf == language.No {
+		return ""
+	}
+	name := display.English.Languages().Name(base)
+	if region, conf := tag.Region(); conf == language.Exact {
+		if r := display.English.Regions().Name(region); r != "" {
+			name += " (" + r + ")"
+		}
+	}
+	return name
+}
@@ -0,0 +1,36 @@
+package po
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProgressFunc receives the progress of a long operation: done units out of
+// total (0 when unknown), with a short message describing the last step.
+// It may be called from several goroutines.
+type ProgressFunc func(done, total int, message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context under which Service methods that work
+// through many catalogs, entries or translation batches report their
+// progress to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress to the ProgressFunc of ctx, if any, for
+// callers that compose Service methods into a longer operation.
+func ReportProgress(ctx context.Context, done, total int, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(done, total, message)
+	}
+}
+
+// progressf is ReportProgress with a formatted message, built only when
+// someone listens.
+func progressf(ctx context.Context, done, total int, format string, args ...any) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(done, total, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,30 @@
+package po
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConcatReportsProgress(t *testing.T) {
+	type report struct{ done, total int }
+	var got []report
+	ctx := WithProgress(context.Background(), func(done, total int, message string) {
+		if message == "" {
+			t.Fatalf("progress %d/%d has no message", done, total)
+		}
+		got = append(got, report{done, total})
+	})
+
+	svc := NewService()
+	if _, err := svc.Concat(ctx, []string{samplePO, samplePO}, CatOptions{}); err != nil {
+		t.Fatalf("concat: %v", err)
+	}
+	if len(got) != 2 || got[0] != (report{1, 2}) || got[1] != (report{2, 2}) {
+		t.Fatalf("unexpected progress reports: %+v", got)
+	}
+}
+
+func TestReportProgressWithoutListener(t *testing.T) {
+	// Must not panic when the context carries no ProgressFunc.
+	ReportProgress(context.Background(), 1, 2, "step")
+}
@@ -0,0 +1,162 @@
+package po
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// PseudoOptions controls how Pseudolocalize transforms source strings.
+type PseudoOptions struct {
+	// Locale written to the Language header; defaults to en_XA, or ar_XB
+	// in mirror mode.
+	Locale string
+	// Accents replaces ASCII letters with accented look-alikes.
+	Accents bool
+	// Expansion pads each string by this fraction of its length (0.3 = 30%)
+	// to surface truncation.
+	Expansion float64
+	// Brackets wraps each string in [ ] so clipped or concatenated strings
+	// stand out.
+	Brackets bool
+	// Mirror wraps each string in a right-to-left override to exercise RTL
+	// layouts.
+	Mirror bool
+}
+
+// PseudoResult is the output of Pseudolocalize.
+type PseudoResult struct {
+	Content  string  `json:"po_content"`
+	Base64   string  `json:"mo_base64"`
+	Locale   string  `json:"locale"`
+	Messages int     `json:"messages"`
+	Stats    Summary `json:"stats"`
+}
+
+const (
+	rtlOverride    = "\u202e" // RIGHT-TO-LEFT OVERRIDE
+	popDirectional = "\u202c" // POP DIRECTIONAL FORMATTING
+	pseudoPad      = '~'
+)
+
+var pseudoAccents = map[rune]rune{
+	'a': 'å', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ', 'h': 'ĥ', 'i': 'î',
+	'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ', 'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ',
+	's': 'š', 't': 'ţ', 'u': 'û', 'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Ð', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ', 'H': 'Ĥ', 'I': 'Î',
+	'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ', 'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ',
+	'S': 'Š', 'T': 'Ţ', 'U': 'Û', 'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+// Pseudolocalize builds a synthetic locale catalog from POT or PO content by
+// transforming every msgid, and compiles it to .mo. Printf directives,
+// placeholders and HTML tags are preserved byte for byte. The source
+// Plural-Forms is kept, falling back to the English rule when it is missing
+// or a template, and plural entries get one form per plural: the msgid for
+// the first, the msgid_plural for the others.
+func (s *Service) Pseudolocalize(ctx context.Context, poContent string, opts PseudoOptions) (*PseudoResult, error) {
+	if opts.Expansion < 0 {
+		return nil, fmt.Errorf("expansion must not be negative, got %v", opts.Expansion)
+	}
+	locale := NormalizeLocale(opts.Locale)
+	if locale == "" {
+		locale = "en_XA"
+		if opts.Mirror {
+			locale = "ar_XB"
+		}
+	}
+
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+
+	cat.SetHeaderField("Language", locale)
+	cat.SetHeaderField("Language-Team", "Pseudo-locale")
+	cat.SetHeaderField("Content-Type", "text/plain; charset=UTF-8")
+	nplurals := NPlurals(cat.HeaderField("Plural-Forms"))
+	if nplurals < 1 {
+		nplurals = 2
+		cat.SetHeaderField("Plural-Forms", "nplurals=2; plural=(n != 1);")
+	}
+	cat.Header().RemoveFlag("fuzzy")
+
+	res := &PseudoResult{Locale: locale}
+	out := &Catalog{Entries: []*Entry{cat.Header()}}
+	for _, e := range cat.Messages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if e.Obsolete {
+			continue
+		}
+		e.RemoveFlag("fuzzy")
+		if e.IsPlural() {
+			e.Msgstr = make([]string, nplurals)
+			e.Msgstr[0] = pseudoString(e.Msgid, opts)
+			for i := 1; i < nplurals; i++ {
+				e.Msgstr[i] = pseudoString(e.MsgidPlural, opts)
+			}
+		} else {
+			e.Msgstr = []string{pseudoString(e.Msgid, opts)}
+		}
+		out.Entries = append(out.Entries, e)
+		res.Messages++
+	}
+
+	res.Content = out.String()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	moBin, stats, err := compileMO(res.Content)
+	if err != nil {
+		return nil, err
+	}
+	res.Base64 = base64.StdEncoding.EncodeToString(moBin)
+	res.Stats = stats
+	return res, nil
+}
+
+// pseudoString transforms one source string. A trailing newline stays last
+// so msgid and msgstr keep matching line endings.
+func pseudoString(src string, opts PseudoOptions) string {
+	body, nl := strings.CutSuffix(src, "\n")
+	if body == "" {
+		return src
+	}
+
+	var b strings.Builder
+	textRunes := 0
+	for _, seg := range splitPlaceholders(body) {
+		if seg.placeholder {
+			b.WriteString(seg.text)
+			continue
+		}
+		for _, r := range seg.text {
+			textRunes++
+			if opts.Accents {
+				if a, ok := pseudoAccents[r]; ok {
+					r = a
+				}
+			}
+			b.WriteRune(r)
+		}
+	}
+
+	out := b.String()
+	if pad := int(math.Ceil(float64(textRunes) * opts.Expansion)); pad > 0 {
+		out += strings.Repeat(string(pseudoPad), pad)
+	}
+	if opts.Brackets {
+		out = "[" + out + "]"
+	}
+	if opts.Mirror {
+		out = rtlOverride + out + popDirectional
+	}
+	if nl {
+		out += "\n"
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package po
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	gotext "github.com/leonelquinteros/gotext"
+)
+
+func TestPseudoStringPreservesPlaceholders(t *testing.T) {
+	opts := PseudoOptions{Accents: true, Expansion: 0.5, Brackets: true}
+	got := pseudoString(`Hello %1$s, see <a href="%2$s">{count} posts</a> &amp; more`, opts)
+
+	for _, ph := range []string{"%1$s", `<a href="%2$s">`, "{count}", "</a>", "&amp;"} {
+		if !strings.Contains(got, ph) {
+			t.Fatalf("placeholder %q lost in %q", ph, got)
+		}
+	}
+	if !strings.HasPrefix(got, "[Ĥéļļö") || !strings.HasSuffix(got, "~]") {
+		t.Fatalf("unexpected pseudo string %q", got)
+	}
+	if got := pseudoString("Line\n", opts); !strings.HasSuffix(got, "]\n") {
+		t.Fatalf("trailing newline must stay last, got %q", got)
+	}
+	if got := pseudoString("Abc", PseudoOptions{Mirror: true}); got != rtlOverride+"Abc"+popDirectional {
+		t.Fatalf("unexpected mirrored string %q", got)
+	}
+}
+
+func TestPseudolocalizeCompiles(t *testing.T) {
+	svc := NewService()
+	res, err := svc.Pseudolocalize(context.Background(), samplePO, PseudoOptions{Accents: true, Brackets: true})
+	if err != nil {
+		t.Fatalf("pseudolocalize returned error: %v", err)
+	}
+	if res.Locale != "en_XA" || res.Messages != 3 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	moBytes, err := base64.StdEncoding.DecodeString(res.Base64)
+	if err != nil {
+		t.Fatalf("cannot decode base64: %v", err)
+	}
+	mo := gotext.NewMo()
+	mo.Parse(moBytes)
+	if got := mo.Get("Hello"); got != "[Ĥéļļö]" {
+		t.Fatalf("unexpected translation %q", got)
+	}
+	if got := mo.GetN("File", "Files", 2); got != "[Ƒîļéš]" {
+		t.Fatalf("unexpected plural translation %q", got)
+	}
+	if got := mo.GetC("Open", "menu"); got != "[Öþéñ]" {
+		t.Fatalf("unexpected context translation %q", got)
+	}
+}
+
+func TestPseudolocalizeKeepsPluralForms(t *testing.T) {
+	svc := NewService()
+	res, err := svc.Pseudolocalize(context.Background(), untranslatedRuPO, PseudoOptions{Brackets: true})
+	if err != nil {
+		t.Fatalf("pseudolocalize returned error: %v", err)
+	}
+	cat, err := ParseCatalog(res.Content)
+	if err != nil {
+		t.Fatalf("output does not parse: %v", err)
+	}
+	if got := cat.HeaderField("Plural-Forms"); !strings.HasPrefix(got, "nplurals=3;") {
+		t.Fatalf("the source plural rule was replaced: %q", got)
+	}
+	if e := cat.Find(nil, "%d file"); strings.Join(e.Msgstr, "|") != "[%d file]|[%d files]|[%d files]" {
+		t.Fatalf("expected one form per plural, got %q", e.Msgstr)
+	}
+
+	const pot = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=CHARSET\n"
+"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+`
+	res, err = svc.Pseudolocalize(context.Background(), pot, PseudoOptions{})
+	if err != nil {
+		t.Fatalf("pseudolocalize of a template returned error: %v", err)
+	}
+	if !strings.Contains(res.Content, `"Plural-Forms: nplurals=2; plural=(n != 1);\n"`) {
+		t.Fatalf("a template plural rule must fall back to English:\n%s", res.Content)
+	}
+}
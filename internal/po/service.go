@@ -7,7 +7,6 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
@@ -20,7 +19,32 @@ const (
 )
 
 // Service provides .po parsing, validation, and .mo compilation.
-type Service struct{}
+type Service struct {
+	memory      *Memory
+	glossaryDir string
+	translators map[string]Translator
+	temp        tempStore
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithMemory attaches a translation memory. Compiled and imported catalogs
+// feed it, and suggestions are served from it.
+func WithMemory(m *Memory) Option {
+	return func(s *Service) {
+		s.memory = m
+	}
+}
+
+// CompileOptions controls Compile.
+type CompileOptions struct {
+	// Return is "path" to write the .mo to a server-owned temp file, and
+	// anything else to return it base64-encoded.
+	Return string
+	// DryRun leaves the translation memory untouched.
+	DryRun bool
+}
 
 // CompileResult holds the compiled .mo payload and catalog stats.
 type CompileResult struct {
@@ -39,16 +63,58 @@ type Summary struct {
 }
 
 // NewService constructs a new Service instance.
-func NewService() *Service {
-	return &Service{}
+func NewService(opts ...Option) *Service {
+	s := &Service{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Memory returns the attached translation memory, or nil.
+func (s *Service) Memory() *Memory {
+	return s.memory
 }
 
 // Compile consumes .po content and returns a compiled .mo blob (base64 or path).
-func (s *Service) Compile(ctx context.Context, poContent string, returnMode string) (*CompileResult, error) {
-	domain, err := parseDomain(poContent)
+func (s *Service) Compile(ctx context.Context, poContent string, opts CompileOptions) (*CompileResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	moBin, stats, err := compileMO(poContent)
 	if err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Feeding the translation memory is best effort: a catalog that compiles
+	// must not fail because the memory could not be updated.
+	if s.memory != nil && !opts.DryRun {
+		if cat, err := ParseCatalog(poContent); err == nil {
+			_, _ = s.memory.AddCatalog(cat)
+		}
+	}
+
+	switch strings.ToLower(opts.Return) {
+	case "path":
+		path, err := s.temp.write("messages-*.mo", moBin)
+		if err != nil {
+			return nil, fmt.Errorf("cannot write temp mo file: %w", err)
+		}
+		return &CompileResult{Path: path, Stats: stats}, nil
+	default:
+		return &CompileResult{Base64: base64.StdEncoding.EncodeToString(moBin), Stats: stats}, nil
+	}
+}
+
+// compileMO builds the .mo binary and stats for .po content.
+func compileMO(poContent string) ([]byte, Summary, error) {
+	domain, err := parseDomain(poContent)
+	if err != nil {
+		return nil, Summary{}, err
+	}
 
 	entries := domainToEntries(domain)
 
@@ -63,43 +129,30 @@ func (s *Service) Compile(ctx context.Context, poContent string, returnMode stri
 
 	moBin, err := buildMO(entries)
 	if err != nil {
-		return nil, err
+		return nil, Summary{}, err
 	}
 
-	stats := summarizeDomainWithContext(domain, poContent)
-
-	switch strings.ToLower(returnMode) {
-	case "path":
-		f, err := os.CreateTemp("", "mcp-po-*.mo")
-		if err != nil {
-			return nil, fmt.Errorf("cannot create temp mo file: %w", err)
-		}
-		if _, err := f.Write(moBin); err != nil {
-			_ = f.Close()
-			return nil, fmt.Errorf("cannot write temp mo file: %w", err)
-		}
-		if err := f.Close(); err != nil {
-			return nil, fmt.Errorf("cannot close temp mo file: %w", err)
-		}
-		return &CompileResult{Path: f.Name(), Stats: stats}, nil
-	default:
-		return &CompileResult{Base64: base64.StdEncoding.EncodeToString(moBin), Stats: stats}, nil
-	}
+	return moBin, summarizeDomainWithContext(domain, poContent), nil
 }
 
 // Validate analyzes .po content and returns warnings/errors and metrics.
 func (s *Service) Validate(ctx context.Context, poContent string) ([]string, Summary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, Summary{}, err
+	}
 	domain, err := parseDomain(poContent)
 	if err != nil {
 		return nil, Summary{}, err
 	}
 
-	warnings := validateDomain(domain)
-	return warnings, summarizeDomain(domain), nil
+	return diagnosticMessages(validateDomain(domain)), summarizeDomain(domain), nil
 }
 
 // Summarize extracts headers and progress metrics from .po content.
 func (s *Service) Summarize(ctx context.Context, poContent string) (Summary, error) {
+	if err := ctx.Err(); err != nil {
+		return Summary{}, err
+	}
 	domain, err := parseDomain(poContent)
 	if err != nil {
 		return Summary{}, err
@@ -322,7 +375,7 @@ func buildMO(entries []moEntry) ([]byte, error) {
 			return nil, fmt.Errorf("message too large at entry %d", i)
 		}
 
-		binary.LittleEndian.PutUint32(origTable[i*8:], uint32(len(msgid)-1))   // #nosec G115 -- validated above
+		binary.LittleEndian.PutUint32(origTable[i*8:], uint32(len(msgid)-1)) // #nosec G115 -- validated above
 		binary.LittleEndian.PutUint32(origTable[i*8+4:], curOffset)
 		data.Write(msgid)
 		curOffset += uint32(len(msgid)) // #nosec G115 -- validated above
@@ -424,27 +477,29 @@ func translated(tr *gotext.Translation) bool {
 	return true
 }
 
-// validateDomain produces warnings for missing headers or empty translations.
-func validateDomain(domain *gotext.Domain) []string {
-	warnings := make([]string, 0)
+// validateDomain reports missing headers and empty translations.
+func validateDomain(domain *gotext.Domain) []Diagnostic {
+	diags := make([]Diagnostic, 0)
 
-	if strings.TrimSpace(domain.Language) == "" {
-		warnings = append(warnings, "Language header missing")
-	}
-	if strings.TrimSpace(domain.PluralForms) == "" {
-		warnings = append(warnings, "Plural-Forms header missing")
+	for _, h := range []struct{ name, value string }{
+		{"Language", domain.Language},
+		{"Plural-Forms", domain.PluralForms},
+	} {
+		if strings.TrimSpace(h.value) == "" {
+			diags = append(diags, Diagnostic{Code: DiagMissingHeader, Message: h.name + " header missing", Header: h.name})
+		}
 	}
 
 	translations := domain.GetTranslations()
 	delete(translations, "")
 	for id, tr := range translations {
 		if !translated(tr) {
-			warnings = append(warnings, fmt.Sprintf("untranslated entry: %s", id))
+			diags = append(diags, Diagnostic{Code: DiagUntranslated, Message: fmt.Sprintf("untranslated entry: %s", id), Msgid: id})
 		}
 	}
 
 	// Note: gotext library doesn't expose context translations via public API
 
-	sort.Strings(warnings)
-	return warnings
+	sortDiagnostics(diags)
+	return diags
 }
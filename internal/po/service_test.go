@@ -3,6 +3,7 @@ package po
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"os"
 	"testing"
 
@@ -33,7 +34,7 @@ msgstr "Abrir"
 func TestCompileProducesReadableMo(t *testing.T) {
 	svc := NewService()
 
-	res, err := svc.Compile(context.Background(), samplePO, "base64")
+	res, err := svc.Compile(context.Background(), samplePO, CompileOptions{Return: "base64"})
 	if err != nil {
 		t.Fatalf("compile returned error: %v", err)
 	}
@@ -72,7 +73,7 @@ func TestCompileProducesReadableMo(t *testing.T) {
 
 func TestCompileToPath(t *testing.T) {
 	svc := NewService()
-	res, err := svc.Compile(context.Background(), samplePO, "path")
+	res, err := svc.Compile(context.Background(), samplePO, CompileOptions{Return: "path"})
 	if err != nil {
 		t.Fatalf("compile returned error: %v", err)
 	}
@@ -82,7 +83,7 @@ func TestCompileToPath(t *testing.T) {
 	if _, err := os.Stat(res.Path); err != nil {
 		t.Fatalf("temp mo file missing: %v", err)
 	}
-	t.Cleanup(func() { _ = os.Remove(res.Path) })
+	t.Cleanup(func() { _ = svc.Close() })
 }
 
 func TestValidateWarnings(t *testing.T) {
@@ -100,3 +101,37 @@ func TestValidateWarnings(t *testing.T) {
 		t.Fatalf("unexpected summary: %+v", summary)
 	}
 }
+
+func TestCancelledContext(t *testing.T) {
+	mem, err := OpenMemory("")
+	if err != nil {
+		t.Fatalf("open memory: %v", err)
+	}
+	svc := NewService(WithMemory(mem))
+	t.Cleanup(func() { _ = svc.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"Compile":  func() error { _, err := svc.Compile(ctx, samplePO, CompileOptions{Return: "base64"}); return err },
+		"Validate": func() error { _, _, err := svc.Validate(ctx, samplePO); return err },
+		"Filter": func() error {
+			_, err := svc.Filter(ctx, samplePO, EntryFilter{}, FilterOptions{})
+			return err
+		},
+		"ListEntries": func() error {
+			_, err := svc.ListEntries(ctx, samplePO, EntryFilter{}, ListOptions{})
+			return err
+		},
+		"Concat": func() error {
+			_, err := svc.Concat(ctx, []string{samplePO, samplePO}, CatOptions{})
+			return err
+		},
+		"Suggest": func() error { _, err := svc.Suggest(ctx, samplePO, SuggestOptions{}); return err },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+}
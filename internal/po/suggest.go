@@ -0,0 +1,101 @@
+package po
+
+import (
+	"context"
+	"errors"
+)
+
+// errNoMemory is returned by memory-backed operations when no translation
+// memory is attached to the Service.
+var errNoMemory = errors.New("translation memory is not configured")
+
+// SuggestOptions tunes Suggest.
+type SuggestOptions struct {
+	// MinScore is the lowest similarity (0-1) reported; defaults to 0.7.
+	MinScore float64
+	// Limit caps the matches per entry; defaults to 3.
+	Limit int
+}
+
+// EntrySuggestions holds memory matches for one untranslated entry.
+type EntrySuggestions struct {
+	Msgctxt string        `json:"msgctxt,omitempty"`
+	Msgid   string        `json:"msgid"`
+	Matches []MemoryMatch `json:"matches"`
+}
+
+// SuggestResult is the output of Suggest.
+type SuggestResult struct {
+	Locale       string             `json:"locale"`
+	Untranslated int                `json:"untranslated"`
+	Suggestions  []EntrySuggestions `json:"suggestions"`
+}
+
+// ImportMemoryResult is the output of ImportMemory.
+type ImportMemoryResult struct {
+	Locale string `json:"locale"`
+	Added  int    `json:"added"`
+	Total  int    `json:"total"`
+}
+
+// ImportMemory stores the translated entries of a catalog in the memory.
+func (s *Service) ImportMemory(ctx context.Context, poContent string) (*ImportMemoryResult, error) {
+	if s.memory == nil {
+		return nil, errNoMemory
+	}
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	added, err := s.memory.AddCatalog(cat)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportMemoryResult{Locale: NormalizeLocale(cat.HeaderField("Language")), Added: added, Total: s.memory.Len()}, nil
+}
+
+// Suggest looks up exact and fuzzy memory matches for the untranslated and
+// fuzzy entries of a catalog, in the catalog's Language.
+func (s *Service) Suggest(ctx context.Context, poContent string, opts SuggestOptions) (*SuggestResult, error) {
+	if s.memory == nil {
+		return nil, errNoMemory
+	}
+	if opts.MinScore <= 0 {
+		opts.MinScore = 0.7
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 3
+	}
+
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	locale := cat.HeaderField("Language")
+	if locale == "" {
+		return nil, errors.New("catalog has no Language header")
+	}
+
+	res := &SuggestResult{Locale: NormalizeLocale(locale), Suggestions: []EntrySuggestions{}}
+	msgs := cat.Messages()
+	for i, e := range msgs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		progressf(ctx, i, len(msgs), "looked up %d of %d entries", i, len(msgs))
+		if st := e.State(); st != StateUntranslated && st != StateFuzzy {
+			continue
+		}
+		res.Untranslated++
+		matches := s.memory.Lookup(locale, e.Msgctxt, e.Msgid, opts.MinScore, opts.Limit)
+		if len(matches) == 0 {
+			continue
+		}
+		res.Suggestions = append(res.Suggestions, EntrySuggestions{Msgctxt: e.Msgctxt, Msgid: e.Msgid, Matches: matches})
+	}
+	progressf(ctx, len(msgs), len(msgs), "looked up %d entries", len(msgs))
+	return res, nil
+}
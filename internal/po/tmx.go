@@ -0,0 +1,389 @@
+package po
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tmxDateLayout   = "20060102T150405Z"
+	tmxCreationTool = "mcp-po-compiler"
+
+	// TMX properties used to carry gettext data that has no TMX equivalent.
+	tmxPropContext      = "x-context"
+	tmxPropMsgidPlural  = "x-msgid-plural"
+	tmxPropOrigin       = "x-origin"
+	tmxPropPluralPrefix = "x-msgstr-"
+)
+
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Body    tmxBody   `xml:"body"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+	CreationDate        string `xml:"creationdate,attr,omitempty"`
+}
+
+type tmxBody struct {
+	TUs []tmxTU `xml:"tu"`
+}
+
+type tmxTU struct {
+	TUID         string    `xml:"tuid,attr,omitempty"`
+	SrcLang      string    `xml:"srclang,attr,omitempty"`
+	CreationDate string    `xml:"creationdate,attr,omitempty"`
+	ChangeDate   string    `xml:"changedate,attr,omitempty"`
+	Notes        []string  `xml:"note"`
+	Props        []tmxProp `xml:"prop"`
+	TUVs         []tmxTUV  `xml:"tuv"`
+}
+
+type tmxTUV struct {
+	Lang       string    `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	LegacyLang string    `xml:"lang,attr,omitempty"` // TMX 1.1 used a plain lang attribute
+	Props      []tmxProp `xml:"prop"`
+	Seg        tmxSeg    `xml:"seg"`
+}
+
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// tmxSeg keeps the raw segment so inline markup (<ph>, <bpt>, <hi>...) can be
+// flattened on import.
+type tmxSeg struct {
+	Inner string `xml:",innerxml"`
+}
+
+// TMXImportOptions controls ImportTMX.
+type TMXImportOptions struct {
+	// Locales restricts the imported target languages; empty imports all.
+	Locales []string
+}
+
+// TMXImportResult is the output of ImportTMX.
+type TMXImportResult struct {
+	Units   int      `json:"units"`
+	Added   int      `json:"added"`
+	Skipped int      `json:"skipped"`
+	Locales []string `json:"locales"`
+	Total   int      `json:"total"`
+}
+
+// TMXExportOptions controls the TMX exports.
+type TMXExportOptions struct {
+	// SourceLanguage is the language of msgid; defaults to "en".
+	SourceLanguage string
+}
+
+// TMXExportResult is the output of ExportTMX and ExportMemoryTMX.
+type TMXExportResult struct {
+	Content string `json:"tmx_content"`
+	Units   int    `json:"units"`
+}
+
+// ImportTMX reads a TMX 1.4b document into the translation memory. Every
+// target-language variant of a translation unit becomes one memory unit;
+// notes, creation and change dates, and the x-context property are kept.
+func (s *Service) ImportTMX(ctx context.Context, tmxContent string, opts TMXImportOptions) (*TMXImportResult, error) {
+	if s.memory == nil {
+		return nil, errNoMemory
+	}
+	if strings.TrimSpace(tmxContent) == "" {
+		return nil, errors.New("empty tmx content")
+	}
+
+	var doc tmxDocument
+	if err := xml.Unmarshal([]byte(tmxContent), &doc); err != nil {
+		return nil, fmt.Errorf("parse tmx: %w", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, l := range opts.Locales {
+		wanted[NormalizeLocale(l)] = true
+	}
+
+	res := &TMXImportResult{Locales: []string{}}
+	seenLocale := make(map[string]bool)
+	var units []MemoryEntry
+	for i, tu := range doc.Body.TUs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		progressf(ctx, i, len(doc.Body.TUs), "read %d of %d translation units", i, len(doc.Body.TUs))
+		res.Units++
+		srcLang := tu.SrcLang
+		if srcLang == "" || srcLang == "*all*" {
+			srcLang = doc.Header.SrcLang
+		}
+
+		src := -1
+		for i, tuv := range tu.TUVs {
+			if sameLanguage(tuv.lang(), srcLang) {
+				src = i
+				break
+			}
+		}
+		if src < 0 {
+			res.Skipped++
+			continue
+		}
+		source, err := tu.TUVs[src].Seg.text()
+		if err != nil {
+			return nil, fmt.Errorf("tu %q: %w", tu.TUID, err)
+		}
+
+		base := MemoryEntry{
+			Source:       source,
+			Context:      propValue(tu.Props, tmxPropContext),
+			SourcePlural: propValue(tu.Props, tmxPropMsgidPlural),
+			Origin:       propValue(tu.Props, tmxPropOrigin),
+			Note:         strings.Join(tu.Notes, "\n"),
+			Created:      parseTMXDate(tu.CreationDate),
+			Updated:      parseTMXDate(tu.ChangeDate),
+		}
+
+		for i, tuv := range tu.TUVs {
+			if i == src {
+				continue
+			}
+			locale := NormalizeLocale(tuv.lang())
+			if len(wanted) > 0 && !wanted[locale] {
+				continue
+			}
+			target, err := tuv.Seg.text()
+			if err != nil {
+				return nil, fmt.Errorf("tu %q: %w", tu.TUID, err)
+			}
+			unit := base
+			unit.Locale = locale
+			unit.Target = append([]string{target}, pluralProps(tuv.Props)...)
+			units = append(units, unit)
+			if !seenLocale[locale] {
+				seenLocale[locale] = true
+				res.Locales = append(res.Locales, locale)
+			}
+		}
+	}
+
+	added, err := s.memory.Add(units...)
+	if err != nil {
+		return nil, err
+	}
+	progressf(ctx, len(doc.Body.TUs), len(doc.Body.TUs), "imported %d memory units", added)
+	res.Added = added
+	res.Total = s.memory.Len()
+	return res, nil
+}
+
+// ExportTMX converts the translated entries of a catalog into a TMX document.
+func (s *Service) ExportTMX(ctx context.Context, poContent string, opts TMXExportOptions) (*TMXExportResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	locale := cat.HeaderField("Language")
+	if locale == "" {
+		return nil, errors.New("catalog has no Language header")
+	}
+
+	origin := cat.HeaderField("Project-Id-Version")
+	var units []MemoryEntry
+	for _, e := range cat.Messages() {
+		if e.State() != StateTranslated {
+			continue
+		}
+		units = append(units, MemoryEntry{
+			Locale:       NormalizeLocale(locale),
+			Context:      e.Msgctxt,
+			Source:       e.Msgid,
+			SourcePlural: e.MsgidPlural,
+			Target:       e.Msgstr,
+			Note:         strings.Join(e.ExtractedComments, "\n"),
+			Origin:       origin,
+		})
+	}
+	return writeTMX(units, opts)
+}
+
+// ExportMemoryTMX exports the translation memory units of one locale (or of
+// every locale when empty) as a TMX document.
+func (s *Service) ExportMemoryTMX(ctx context.Context, locale string, opts TMXExportOptions) (*TMXExportResult, error) {
+	if s.memory == nil {
+		return nil, errNoMemory
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return writeTMX(s.memory.Entries(locale), opts)
+}
+
+// writeTMX renders memory units as TMX 1.4b, one translation unit per unit.
+func writeTMX(units []MemoryEntry, opts TMXExportOptions) (*TMXExportResult, error) {
+	srcLang := opts.SourceLanguage
+	if srcLang == "" {
+		srcLang = "en"
+	}
+	srcLang = tmxLang(srcLang)
+
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        tmxCreationTool,
+			CreationToolVersion: "1.0",
+			SegType:             "sentence",
+			OTMF:                "PO",
+			AdminLang:           "en",
+			SrcLang:             srcLang,
+			DataType:            "plaintext",
+			CreationDate:        time.Now().UTC().Format(tmxDateLayout),
+		},
+	}
+
+	for _, u := range units {
+		tu := tmxTU{
+			CreationDate: formatTMXDate(u.Created),
+			ChangeDate:   formatTMXDate(u.Updated),
+		}
+		if u.Note != "" {
+			tu.Notes = []string{u.Note}
+		}
+		if u.Context != "" {
+			tu.Props = append(tu.Props, tmxProp{Type: tmxPropContext, Value: u.Context})
+		}
+		if u.SourcePlural != "" {
+			tu.Props = append(tu.Props, tmxProp{Type: tmxPropMsgidPlural, Value: u.SourcePlural})
+		}
+		if u.Origin != "" {
+			tu.Props = append(tu.Props, tmxProp{Type: tmxPropOrigin, Value: u.Origin})
+		}
+
+		target := tmxTUV{Lang: tmxLang(u.Locale)}
+		if len(u.Target) > 0 {
+			target.Seg = newTMXSeg(u.Target[0])
+		}
+		for i := 1; i < len(u.Target); i++ {
+			target.Props = append(target.Props, tmxProp{Type: tmxPropPluralPrefix + strconv.Itoa(i), Value: u.Target[i]})
+		}
+		tu.TUVs = []tmxTUV{{Lang: srcLang, Seg: newTMXSeg(u.Source)}, target}
+		doc.Body.TUs = append(doc.Body.TUs, tu)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode tmx: %w", err)
+	}
+	return &TMXExportResult{Content: xml.Header + string(data) + "\n", Units: len(doc.Body.TUs)}, nil
+}
+
+func (t tmxTUV) lang() string {
+	if t.Lang != "" {
+		return t.Lang
+	}
+	return t.LegacyLang
+}
+
+func newTMXSeg(text string) tmxSeg {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(text))
+	return tmxSeg{Inner: b.String()}
+}
+
+// text flattens a segment to plain text. The content of inline elements is
+// the native code they stand for (e.g. <ph>&lt;br/&gt;</ph>), so all
+// character data is kept.
+func (s tmxSeg) text() (string, error) {
+	dec := xml.NewDecoder(strings.NewReader("<seg>" + s.Inner + "</seg>"))
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("parse seg: %w", err)
+		}
+		if cd, ok := tok.(xml.CharData); ok {
+			b.Write(cd)
+		}
+	}
+}
+
+func propValue(props []tmxProp, typ string) string {
+	for _, p := range props {
+		if p.Type == typ {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// pluralProps returns the extra plural forms stored as x-msgstr-N props.
+func pluralProps(props []tmxProp) []string {
+	var forms []string
+	for i := 1; ; i++ {
+		v, ok := "", false
+		for _, p := range props {
+			if p.Type == tmxPropPluralPrefix+strconv.Itoa(i) {
+				v, ok = p.Value, true
+				break
+			}
+		}
+		if !ok {
+			return forms
+		}
+		forms = append(forms, v)
+	}
+}
+
+// sameLanguage compares TMX language codes; "en" matches "en-US".
+func sameLanguage(a, b string) bool {
+	a, b = NormalizeLocale(a), NormalizeLocale(b)
+	if a == b {
+		return true
+	}
+	la, _, _ := strings.Cut(a, "_")
+	lb, _, _ := strings.Cut(b, "_")
+	return la == lb && (la == a || lb == b)
+}
+
+// tmxLang converts a gettext locale (pt_BR) to an RFC 4646 tag (pt-BR).
+func tmxLang(locale string) string {
+	locale, _, _ = strings.Cut(NormalizeLocale(locale), "@")
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+func parseTMXDate(s string) time.Time {
+	t, err := time.Parse(tmxDateLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func formatTMXDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(tmxDateLayout)
+}
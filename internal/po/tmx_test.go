@@ -0,0 +1,87 @@
+package po
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sdlTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="SDL Language Platform" creationtoolversion="8.0" segtype="sentence"
+          o-tmf="SDL TM8 Format" adminlang="en-US" srclang="en-US" datatype="xml"/>
+  <body>
+    <tu creationdate="20240305T101500Z" changedate="20240406T090000Z">
+      <note>Product page CTA</note>
+      <tuv xml:lang="en-US"><seg>Request a <bpt i="1">&lt;b&gt;</bpt>quote<ept i="1">&lt;/b&gt;</ept></seg></tuv>
+      <tuv xml:lang="es-ES"><seg>Solicita <bpt i="1">&lt;b&gt;</bpt>presupuesto<ept i="1">&lt;/b&gt;</ept></seg></tuv>
+      <tuv xml:lang="fr-FR"><seg>Demander un <bpt i="1">&lt;b&gt;</bpt>devis<ept i="1">&lt;/b&gt;</ept></seg></tuv>
+    </tu>
+    <tu>
+      <prop type="x-context">menu</prop>
+      <tuv xml:lang="en-US"><seg>Open</seg></tuv>
+      <tuv xml:lang="es-ES"><seg>Abrir</seg></tuv>
+    </tu>
+  </body>
+</tmx>`
+
+func TestImportTMX(t *testing.T) {
+	mem, _ := OpenMemory("")
+	svc := NewService(WithMemory(mem))
+
+	res, err := svc.ImportTMX(context.Background(), sdlTMX, TMXImportOptions{Locales: []string{"es-ES"}})
+	if err != nil {
+		t.Fatalf("import returned error: %v", err)
+	}
+	if res.Units != 2 || res.Added != 2 || len(res.Locales) != 1 || res.Locales[0] != "es_ES" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	matches := mem.Lookup("es_ES", "", "Request a <b>quote</b>", 1, 1)
+	if len(matches) != 1 {
+		t.Fatalf("expected the inline markup to be flattened to native code")
+	}
+	got := matches[0].Entry
+	if got.Target[0] != "Solicita <b>presupuesto</b>" || got.Note != "Product page CTA" {
+		t.Fatalf("unexpected unit: %+v", got)
+	}
+	if !got.Created.Equal(time.Date(2024, 3, 5, 10, 15, 0, 0, time.UTC)) {
+		t.Fatalf("creation date not kept: %v", got.Created)
+	}
+	if m := mem.Lookup("es_ES", "menu", "Open", 1, 1); len(m) != 1 {
+		t.Fatalf("expected context to be imported from x-context")
+	}
+}
+
+func TestExportTMXRoundTrip(t *testing.T) {
+	svc := NewService()
+	exported, err := svc.ExportTMX(context.Background(), samplePO, TMXExportOptions{})
+	if err != nil {
+		t.Fatalf("export returned error: %v", err)
+	}
+	if exported.Units != 3 || !strings.Contains(exported.Content, `<tuv xml:lang="es">`) {
+		t.Fatalf("unexpected export:\n%s", exported.Content)
+	}
+
+	mem, _ := OpenMemory("")
+	svc = NewService(WithMemory(mem))
+	if _, err := svc.ImportTMX(context.Background(), exported.Content, TMXImportOptions{}); err != nil {
+		t.Fatalf("re-import returned error: %v", err)
+	}
+	file := mem.Lookup("es", "", "File", 1, 1)
+	if len(file) != 1 || file[0].Entry.SourcePlural != "Files" || strings.Join(file[0].Entry.Target, "|") != "Archivo|Archivos" {
+		t.Fatalf("plural unit not preserved: %+v", file)
+	}
+	if open := mem.Lookup("es", "menu", "Open", 1, 1); len(open) != 1 || open[0].Entry.Target[0] != "Abrir" {
+		t.Fatalf("context unit not preserved: %+v", open)
+	}
+
+	again, err := svc.ExportMemoryTMX(context.Background(), "es", TMXExportOptions{})
+	if err != nil {
+		t.Fatalf("memory export returned error: %v", err)
+	}
+	if again.Units != 3 {
+		t.Fatalf("expected 3 units from memory, got %d", again.Units)
+	}
+}
@@ -0,0 +1,408 @@
+package po
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MachineTranslatedComment is the extracted comment added to entries filled
+// by a translation backend.
+const MachineTranslatedComment = "machine-translated"
+
+// TranslationUnit is one string handed to a Translator. Placeholders in Text
+// are replaced by ⟦n⟧ tokens that the backend must keep verbatim.
+type TranslationUnit struct {
+	ID       string   `json:"id"`
+	Text     string   `json:"text"`
+	Context  string   `json:"context,omitempty"`
+	Comments []string `json:"comments,omitempty"`
+	// Plural is set for the msgid_plural of a plural entry. A plural entry
+	// has one such unit per plural form after the first, and Form numbers
+	// them as the target Plural-Forms does: 1 to nplurals-1. In languages
+	// with a single form (ja, zh, ko...) a plural entry is one unit of form
+	// 0, translated from its msgid_plural.
+	Plural bool `json:"plural,omitempty"`
+	Form   int  `json:"form,omitempty"`
+
+	placeholders []string
+	// msgid is the msgid of the entry, which keys the translation memory.
+	msgid string
+}
+
+// Source returns the unit text with its placeholders restored.
+func (u TranslationUnit) Source() string {
+	s, err := restorePlaceholders(u.Text, u.placeholders)
+	if err != nil {
+		return u.Text
+	}
+	return s
+}
+
+// TranslationRequest is a batch of units sharing a language pair.
+type TranslationRequest struct {
+	SourceLanguage string            `json:"source_language"`
+	TargetLanguage string            `json:"target_language"`
+	PluralForms    string            `json:"plural_forms,omitempty"`
+	Units          []TranslationUnit `json:"units"`
+}
+
+// Translator is a machine-translation backend. Translate returns one
+// translation per unit, in order; an empty string leaves a unit untranslated.
+// An entry is only filled when its msgid and first plural form are
+// translated (its only unit in single-form languages); later plural forms
+// left empty stay empty in the catalog.
+type Translator interface {
+	Translate(ctx context.Context, req *TranslationRequest) ([]string, error)
+}
+
+// WithTranslator registers a translation backend under name.
+func WithTranslator(name string, t Translator) Option {
+	return func(s *Service) {
+		if s.translators == nil {
+			s.translators = make(map[string]Translator)
+		}
+		s.translators[name] = t
+	}
+}
+
+// Translator returns the backend registered under name. "memory" resolves to
+// the translation memory when no backend of that name was registered.
+func (s *Service) Translator(name string) (Translator, error) {
+	if t, ok := s.translators[name]; ok {
+		return t, nil
+	}
+	if name == "memory" && s.memory != nil {
+		return &MemoryTranslator{Memory: s.memory}, nil
+	}
+	return nil, fmt.Errorf("unknown translation backend %q (available: %s)", name, strings.Join(s.Translators(), ", "))
+}
+
+// Translators lists the names of the available translation backends.
+func (s *Service) Translators() []string {
+	names := make([]string, 0, len(s.translators)+1)
+	for name := range s.translators {
+		names = append(names, name)
+	}
+	if _, ok := s.translators["memory"]; !ok && s.memory != nil {
+		names = append(names, "memory")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PretranslateOptions tunes Pretranslate.
+type PretranslateOptions struct {
+	// SourceLanguage is the language of msgid; defaults to "en".
+	SourceLanguage string
+	// BatchSize caps the units per backend call; defaults to 50.
+	BatchSize int
+	// IncludeFuzzy also retranslates fuzzy entries.
+	IncludeFuzzy bool
+}
+
+// PretranslateResult is the output of Pretranslate.
+type PretranslateResult struct {
+	Content    string   `json:"po_content"`
+	Locale     string   `json:"locale"`
+	Candidates int      `json:"candidates"`
+	Translated int      `json:"translated"`
+	Warnings   []string `json:"warnings"`
+}
+
+// Pretranslate fills the untranslated entries of a catalog through a
+// translation backend. Filled entries are marked fuzzy and carry a
+// "#. machine-translated" comment so a reviewer can find them; entries whose
+// placeholders did not survive the round trip are left untouched.
+func (s *Service) Pretranslate(ctx context.Context, poContent string, tr Translator, opts PretranslateOptions) (*PretranslateResult, error) {
+	if opts.SourceLanguage == "" {
+		opts.SourceLanguage = "en"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	locale := cat.HeaderField("Language")
+	if locale == "" {
+		return nil, errors.New("catalog has no Language header")
+	}
+	pluralForms := cat.HeaderField("Plural-Forms")
+	nplurals := NPlurals(pluralForms)
+	if nplurals <= 0 {
+		nplurals = 2
+	}
+
+	res := &PretranslateResult{Locale: NormalizeLocale(locale), Warnings: []string{}}
+
+	var entries []*Entry
+	for _, e := range cat.Messages() {
+		st := e.State()
+		if st == StateUntranslated || (opts.IncludeFuzzy && st == StateFuzzy) {
+			entries = append(entries, e)
+		}
+	}
+	res.Candidates = len(entries)
+
+	for start := 0; start < len(entries); {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req := &TranslationRequest{
+			SourceLanguage: opts.SourceLanguage,
+			TargetLanguage: res.Locale,
+			PluralForms:    pluralForms,
+		}
+		end := start
+		for end < len(entries) && len(req.Units) < opts.BatchSize {
+			req.Units = append(req.Units, translationUnits(entries[end], nplurals)...)
+			end++
+		}
+
+		out, err := tr.Translate(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("translate: %w", err)
+		}
+		if len(out) != len(req.Units) {
+			return nil, fmt.Errorf("translate: backend returned %d translations for %d units", len(out), len(req.Units))
+		}
+
+		i := 0
+		for _, e := range entries[start:end] {
+			n := 1
+			if e.IsPlural() {
+				n = nplurals
+			}
+			forms, err := restoreUnits(req.Units[i:i+n], out[i:i+n])
+			i += n
+			if err != nil {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("entry %q: %v", e.Msgid, err))
+				continue
+			}
+			if forms == nil {
+				continue
+			}
+			applyTranslation(e, forms, nplurals)
+			res.Translated++
+		}
+		start = end
+		progressf(ctx, end, len(entries), "translated %d of %d entries", end, len(entries))
+	}
+
+	res.Content = cat.String()
+	return res, nil
+}
+
+// translationUnits returns the units of an entry: its msgid, plus its
+// msgid_plural once per plural form after the first for plural entries. When
+// the target language has a single form, a plural entry is only its
+// msgid_plural.
+func translationUnits(e *Entry, nplurals int) []TranslationUnit {
+	unit := func(text string, form int) TranslationUnit {
+		protected, phs := protectPlaceholders(text)
+		id := e.Msgid
+		if e.HasContext {
+			id = e.Msgctxt + "\x04" + id
+		}
+		if form == 1 {
+			id += "\x00plural"
+		} else if form > 1 {
+			id += "\x00plural" + strconv.Itoa(form)
+		}
+		return TranslationUnit{
+			ID:           id,
+			Text:         protected,
+			Context:      e.Msgctxt,
+			Comments:     e.ExtractedComments,
+			Plural:       form > 0,
+			Form:         form,
+			placeholders: phs,
+			msgid:        e.Msgid,
+		}
+	}
+	if e.IsPlural() && nplurals == 1 {
+		u := unit(e.MsgidPlural, 0)
+		u.Plural = true
+		return []TranslationUnit{u}
+	}
+	units := []TranslationUnit{unit(e.Msgid, 0)}
+	if e.IsPlural() {
+		for form := 1; form < nplurals; form++ {
+			units = append(units, unit(e.MsgidPlural, form))
+		}
+	}
+	return units
+}
+
+// restoreUnits restores the placeholders of the translations of one entry.
+// It returns nil when the backend left the msgid or the first plural form
+// empty; later plural forms may stay empty.
+func restoreUnits(units []TranslationUnit, out []string) ([]string, error) {
+	forms := make([]string, len(units))
+	for i, u := range units {
+		if out[i] == "" {
+			if u.Form > 1 {
+				continue
+			}
+			return nil, nil
+		}
+		s, err := restorePlaceholders(out[i], u.placeholders)
+		if err != nil {
+			return nil, err
+		}
+		forms[i] = s
+	}
+	return forms, nil
+}
+
+// applyTranslation stores machine translations in an entry, one per plural
+// form. Forms the backend did not provide stay empty, so that validation
+// reports them instead of a wrong form hiding behind the fuzzy flag.
+func applyTranslation(e *Entry, forms []string, nplurals int) {
+	if !e.IsPlural() {
+		e.Msgstr = []string{forms[0]}
+	} else {
+		e.Msgstr = make([]string, nplurals)
+		copy(e.Msgstr, forms)
+	}
+	e.AddFlag("fuzzy")
+	for _, c := range e.ExtractedComments {
+		if c == MachineTranslatedComment {
+			return
+		}
+	}
+	e.ExtractedComments = append(e.ExtractedComments, MachineTranslatedComment)
+}
+
+// HTTPTranslator calls a generic JSON translation endpoint. It POSTs
+//
+//	{"source_lang": "en", "target_lang": "es", "texts": ["..."]}
+//
+// and expects {"translations": ["..."]} back, one per text. Such endpoints
+// know no plural rules: only the msgid and the first plural form are sent,
+// and the other plural forms are left untranslated.
+type HTTPTranslator struct {
+	Endpoint string
+	// Token is sent as a bearer token when set.
+	Token  string
+	Client *http.Client
+}
+
+// NewHTTPTranslator returns an HTTPTranslator with a 60 second timeout.
+func NewHTTPTranslator(endpoint, token string) *HTTPTranslator {
+	return &HTTPTranslator{Endpoint: endpoint, Token: token, Client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+type httpTranslateRequest struct {
+	SourceLang string   `json:"source_lang"`
+	TargetLang string   `json:"target_lang"`
+	Texts      []string `json:"texts"`
+}
+
+type httpTranslateResponse struct {
+	Translations []string `json:"translations"`
+}
+
+// Translate implements Translator.
+func (t *HTTPTranslator) Translate(ctx context.Context, req *TranslationRequest) ([]string, error) {
+	body := httpTranslateRequest{SourceLang: req.SourceLanguage, TargetLang: req.TargetLanguage}
+	var sent []int
+	for i, u := range req.Units {
+		if u.Form > 1 {
+			continue
+		}
+		body.Texts = append(body.Texts, u.Text)
+		sent = append(sent, i)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if t.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	var out httpTranslateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if len(out.Translations) != len(sent) {
+		return nil, fmt.Errorf("endpoint returned %d translations for %d texts", len(out.Translations), len(sent))
+	}
+	translations := make([]string, len(req.Units))
+	for i, t := range out.Translations {
+		translations[sent[i]] = t
+	}
+	return translations, nil
+}
+
+// MemoryTranslator serves translations from the translation memory.
+type MemoryTranslator struct {
+	Memory *Memory
+	// MinScore is the lowest match score used; defaults to 1 (exact only).
+	MinScore float64
+}
+
+// Translate implements Translator.
+func (t *MemoryTranslator) Translate(ctx context.Context, req *TranslationRequest) ([]string, error) {
+	minScore := t.MinScore
+	if minScore <= 0 {
+		minScore = 1
+	}
+	out := make([]string, len(req.Units))
+	for i, u := range req.Units {
+		if u.Form > 0 {
+			// Filled with the first unit of its entry.
+			continue
+		}
+		source := u.Source()
+		if u.Plural {
+			source = u.msgid
+		}
+		matches := t.Memory.Lookup(req.TargetLanguage, u.Context, source, minScore, 1)
+		if len(matches) == 0 || len(matches[0].Entry.Target) == 0 {
+			continue
+		}
+		target := matches[0].Entry.Target
+		out[i] = protectLike(target[0], u.placeholders)
+		// The plural units of the same entry follow; take each form from the
+		// stored plural translation.
+		for j := i + 1; j < len(req.Units) && req.Units[j].Form > 0; j++ {
+			if form := req.Units[j].Form; form < len(target) {
+				out[j] = protectLike(target[form], req.Units[j].placeholders)
+			}
+		}
+	}
+	return out, nil
+}
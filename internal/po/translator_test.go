@@ -0,0 +1,251 @@
+package po
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const untranslatedPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+"Language: es\n"
+
+msgid "Hello %s, you have <b>%d</b> messages"
+msgstr ""
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "Done"
+msgstr "Hecho"
+`
+
+func TestPlaceholderProtectionRoundTrip(t *testing.T) {
+	protected, phs := protectPlaceholders("Hello %s, <b>{name}</b>")
+	if protected != "Hello ⟦0⟧, ⟦1⟧⟦2⟧⟦3⟧" {
+		t.Fatalf("unexpected protected text: %q", protected)
+	}
+	restored, err := restorePlaceholders("Hola ⟦0⟧, ⟦1⟧⟦2⟧⟦3⟧", phs)
+	if err != nil || restored != "Hola %s, <b>{name}</b>" {
+		t.Fatalf("unexpected restore: %q, %v", restored, err)
+	}
+	if _, err := restorePlaceholders("Hola ⟦0⟧", phs); err == nil {
+		t.Fatalf("expected error for missing placeholder tokens")
+	}
+	if _, err := restorePlaceholders("Hola ⟦0⟧ ⟦0⟧ ⟦1⟧⟦2⟧⟦3⟧", phs); err == nil {
+		t.Fatalf("expected error for repeated placeholder tokens")
+	}
+}
+
+func TestPretranslateHTTPBackend(t *testing.T) {
+	var got httpTranslateRequest
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		out := httpTranslateResponse{}
+		for _, text := range got.Texts {
+			text = strings.NewReplacer("Hello", "Hola", "you have", "tienes", "messages", "mensajes", "files", "archivos", "file", "archivo").Replace(text)
+			out.Translations = append(out.Translations, text)
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer stub.Close()
+
+	svc := NewService(WithTranslator("http", NewHTTPTranslator(stub.URL, "secret")))
+	tr, err := svc.Translator("http")
+	if err != nil {
+		t.Fatalf("translator returned error: %v", err)
+	}
+
+	res, err := svc.Pretranslate(context.Background(), untranslatedPO, tr, PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	if got.SourceLang != "en" || got.TargetLang != "es" || len(got.Texts) != 3 {
+		t.Fatalf("unexpected backend request: %+v", got)
+	}
+	if strings.Contains(got.Texts[0], "%s") || !strings.Contains(got.Texts[0], "⟦0⟧") {
+		t.Fatalf("placeholders were not protected: %q", got.Texts[0])
+	}
+	if res.Candidates != 2 || res.Translated != 2 {
+		t.Fatalf("expected 2 of 2 entries translated, got %+v", res)
+	}
+
+	cat, err := ParseCatalog(res.Content)
+	if err != nil {
+		t.Fatalf("output does not parse: %v", err)
+	}
+	e := cat.Find(nil, "Hello %s, you have <b>%d</b> messages")
+	if e.Msgstr[0] != "Hola %s, tienes <b>%d</b> mensajes" {
+		t.Fatalf("unexpected translation: %q", e.Msgstr[0])
+	}
+	if !e.IsFuzzy() || !strings.Contains(res.Content, "#. machine-translated\n#, fuzzy\nmsgid \"Hello") {
+		t.Fatalf("expected fuzzy machine-translated entry, got:\n%s", res.Content)
+	}
+	plural := cat.Find(nil, "%d file")
+	if len(plural.Msgstr) != 2 || plural.Msgstr[0] != "%d archivo" || plural.Msgstr[1] != "%d archivos" {
+		t.Fatalf("unexpected plural forms: %q", plural.Msgstr)
+	}
+	if done := cat.Find(nil, "Done"); done.IsFuzzy() || done.Msgstr[0] != "Hecho" {
+		t.Fatalf("translated entry must be left alone: %+v", done)
+	}
+}
+
+func TestPretranslateRejectsDamagedPlaceholders(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpTranslateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		out := httpTranslateResponse{}
+		for range req.Texts {
+			out.Translations = append(out.Translations, "sin marcadores")
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer stub.Close()
+
+	svc := NewService()
+	res, err := svc.Pretranslate(context.Background(), untranslatedPO, NewHTTPTranslator(stub.URL, ""), PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	if res.Translated != 0 || len(res.Warnings) != 2 {
+		t.Fatalf("expected both entries rejected with warnings, got %+v", res)
+	}
+	if strings.Contains(res.Content, "sin marcadores") {
+		t.Fatalf("damaged translation was written:\n%s", res.Content)
+	}
+}
+
+func TestPretranslateMemoryBackend(t *testing.T) {
+	mem, _ := OpenMemory("")
+	_, err := mem.Add(
+		MemoryEntry{Locale: "es", Source: "Hello %s, you have <b>%d</b> messages", Target: []string{"Hola %s, tienes <b>%d</b> mensajes"}},
+		MemoryEntry{Locale: "es", Source: "%d file", SourcePlural: "%d files", Target: []string{"%d archivo", "%d archivos"}},
+	)
+	if err != nil {
+		t.Fatalf("add returned error: %v", err)
+	}
+	svc := NewService(WithMemory(mem))
+	tr, err := svc.Translator("memory")
+	if err != nil {
+		t.Fatalf("translator returned error: %v", err)
+	}
+
+	res, err := svc.Pretranslate(context.Background(), untranslatedPO, tr, PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	if res.Translated != 2 {
+		t.Fatalf("expected 2 entries translated from memory, got %+v", res)
+	}
+	if !strings.Contains(res.Content, `msgstr[1] "%d archivos"`) {
+		t.Fatalf("plural forms not filled from memory:\n%s", res.Content)
+	}
+
+	if _, err := NewService().Translator("memory"); err == nil {
+		t.Fatalf("expected error without a memory")
+	}
+}
+
+const untranslatedRuPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+"Language: ru\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+msgstr[2] ""
+`
+
+func TestPretranslateHTTPBackendLeavesUnknownPluralForms(t *testing.T) {
+	var got httpTranslateRequest
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(httpTranslateResponse{Translations: []string{"⟦0⟧ файл", "⟦0⟧ файла"}})
+	}))
+	defer stub.Close()
+
+	res, err := NewService().Pretranslate(context.Background(), untranslatedRuPO, NewHTTPTranslator(stub.URL, ""), PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	if len(got.Texts) != 2 || res.Translated != 1 {
+		t.Fatalf("expected the msgid and one plural form sent and the entry filled, got %q, %+v", got.Texts, res)
+	}
+	cat, _ := ParseCatalog(res.Content)
+	e := cat.Find(nil, "%d file")
+	if len(e.Msgstr) != 3 || e.Msgstr[0] != "%d файл" || e.Msgstr[1] != "%d файла" || e.Msgstr[2] != "" {
+		t.Fatalf("the third form must stay untranslated, got %q", e.Msgstr)
+	}
+}
+
+func TestPretranslateMemoryBackendAllPluralForms(t *testing.T) {
+	mem, _ := OpenMemory("")
+	if _, err := mem.Add(MemoryEntry{Locale: "ru", Source: "%d file", SourcePlural: "%d files", Target: []string{"%d файл", "%d файла", "%d файлов"}}); err != nil {
+		t.Fatalf("add returned error: %v", err)
+	}
+	res, err := NewService().Pretranslate(context.Background(), untranslatedRuPO, &MemoryTranslator{Memory: mem}, PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	cat, _ := ParseCatalog(res.Content)
+	if e := cat.Find(nil, "%d file"); strings.Join(e.Msgstr, "|") != "%d файл|%d файла|%d файлов" {
+		t.Fatalf("plural forms not all filled from memory: %q", e.Msgstr)
+	}
+}
+
+const untranslatedJaPO = `msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=1; plural=0;\n"
+"Language: ja\n"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+`
+
+func TestPretranslateSinglePluralForm(t *testing.T) {
+	mem, _ := OpenMemory("")
+	if _, err := mem.Add(MemoryEntry{Locale: "ja", Source: "%d file", SourcePlural: "%d files", Target: []string{"%d 個のファイル"}}); err != nil {
+		t.Fatalf("add returned error: %v", err)
+	}
+	res, err := NewService().Pretranslate(context.Background(), untranslatedJaPO, &MemoryTranslator{Memory: mem}, PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	cat, _ := ParseCatalog(res.Content)
+	if e := cat.Find(nil, "%d file"); res.Translated != 1 || len(e.Msgstr) != 1 || e.Msgstr[0] != "%d 個のファイル" {
+		t.Fatalf("plural entry not filled from memory: %+v, %q", res, e.Msgstr)
+	}
+
+	var got httpTranslateRequest
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(httpTranslateResponse{Translations: []string{"⟦0⟧ 個のファイル"}})
+	}))
+	defer stub.Close()
+	res, err = NewService().Pretranslate(context.Background(), untranslatedJaPO, NewHTTPTranslator(stub.URL, ""), PretranslateOptions{})
+	if err != nil {
+		t.Fatalf("pretranslate returned error: %v", err)
+	}
+	if len(got.Texts) != 1 || got.Texts[0] != "⟦0⟧ files" || res.Translated != 1 {
+		t.Fatalf("expected only the msgid_plural sent, got %q, %+v", got.Texts, res)
+	}
+}
@@ -0,0 +1,148 @@
+package po
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ContentHash returns a short hash of catalog content. Tools report it so a
+// later edit can detect that the catalog changed in between.
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:8])
+}
+
+// EntryEdit changes one entry, addressed by ID or by msgctxt and msgid.
+type EntryEdit struct {
+	ID      string  `json:"id,omitempty"`
+	Msgctxt *string `json:"msgctxt,omitempty"`
+	Msgid   string  `json:"msgid,omitempty"`
+
+	// Msgstr replaces the translation of a singular entry.
+	Msgstr *string `json:"msgstr,omitempty"`
+	// MsgstrPlural replaces all translated forms of a plural entry.
+	MsgstrPlural []string `json:"msgstr_plural,omitempty"`
+	AddFlags     []string `json:"add_flags,omitempty"`
+	RemoveFlags  []string `json:"remove_flags,omitempty"`
+	// Comments are appended as translator comments.
+	Comments []string `json:"comments,omitempty"`
+}
+
+// UpdateOptions controls UpdateEntries.
+type UpdateOptions struct {
+	// BaseHash, when set, must match the ContentHash of the catalog.
+	BaseHash string
+}
+
+// UpdateResult is the output of UpdateEntries.
+type UpdateResult struct {
+	Content string `json:"po_content"`
+	Hash    string `json:"hash"`
+	Updated int    `json:"updated"`
+	Diff    string `json:"diff"`
+}
+
+// UpdateEntries applies a batch of edits to a catalog. The batch is atomic:
+// if any edit cannot be applied, or the catalog no longer matches BaseHash,
+// nothing is changed and an error is returned.
+func (s *Service) UpdateEntries(ctx context.Context, poContent string, edits []EntryEdit, opts UpdateOptions) (*UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.BaseHash != "" && opts.BaseHash != ContentHash(poContent) {
+		return nil, fmt.Errorf("catalog changed since it was read (hash %s, expected %s); read it again", ContentHash(poContent), opts.BaseHash)
+	}
+	if len(edits) == 0 {
+		return nil, errors.New("no edits given")
+	}
+
+	cat, err := ParseCatalog(poContent)
+	if err != nil {
+		return nil, err
+	}
+	nplurals := NPlurals(cat.HeaderField("Plural-Forms"))
+
+	for i, edit := range edits {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		e, err := findEdited(cat, edit)
+		if err == nil {
+			err = applyEdit(e, edit, nplurals)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %w", i+1, err)
+		}
+	}
+
+	// Writing the catalog normalizes layout; diff against the normalized
+	// original so only the edits show.
+	before, _ := ParseCatalog(poContent)
+	out := cat.String()
+	return &UpdateResult{
+		Content: out,
+		Hash:    ContentHash(out),
+		Updated: len(edits),
+		Diff:    UnifiedDiff("a/messages.po", "b/messages.po", before.String(), out),
+	}, nil
+}
+
+// findEdited returns the live entry addressed by an edit.
+func findEdited(cat *Catalog, edit EntryEdit) (*Entry, error) {
+	if edit.ID == "" && edit.Msgid == "" {
+		return nil, errors.New("id or msgid is required")
+	}
+	for _, e := range cat.Messages() {
+		if e.Obsolete {
+			continue
+		}
+		if edit.ID != "" {
+			if e.ID() == edit.ID {
+				return e, nil
+			}
+			continue
+		}
+		if e.Msgid != edit.Msgid {
+			continue
+		}
+		if edit.Msgctxt == nil && !e.HasContext || edit.Msgctxt != nil && e.HasContext && e.Msgctxt == *edit.Msgctxt {
+			return e, nil
+		}
+	}
+	if edit.ID != "" {
+		return nil, fmt.Errorf("no entry with id %s", edit.ID)
+	}
+	if edit.Msgctxt != nil {
+		return nil, fmt.Errorf("no entry with msgctxt %q and msgid %q", *edit.Msgctxt, edit.Msgid)
+	}
+	return nil, fmt.Errorf("no entry with msgid %q", edit.Msgid)
+}
+
+func applyEdit(e *Entry, edit EntryEdit, nplurals int) error {
+	if edit.Msgstr != nil {
+		if e.IsPlural() {
+			return fmt.Errorf("entry %q is plural; use msgstr_plural", e.Msgid)
+		}
+		e.Msgstr = []string{*edit.Msgstr}
+	}
+	if edit.MsgstrPlural != nil {
+		if !e.IsPlural() {
+			return fmt.Errorf("entry %q is not plural; use msgstr", e.Msgid)
+		}
+		if nplurals > 0 && len(edit.MsgstrPlural) != nplurals {
+			return fmt.Errorf("entry %q needs %d plural forms, got %d", e.Msgid, nplurals, len(edit.MsgstrPlural))
+		}
+		e.Msgstr = append([]string(nil), edit.MsgstrPlural...)
+	}
+	for _, f := range edit.AddFlags {
+		e.AddFlag(f)
+	}
+	for _, f := range edit.RemoveFlags {
+		e.RemoveFlag(f)
+	}
+	e.Comments = append(e.Comments, edit.Comments...)
+	return nil
+}
@@ -0,0 +1,70 @@
+package po
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUpdateEntriesAppliesEdits(t *testing.T) {
+	svc := NewService()
+	cat, _ := ParseCatalog(samplePO)
+	fileID := cat.Find(nil, "File").ID()
+
+	menu := "menu"
+	hola := "¡Hola!"
+	res, err := svc.UpdateEntries(context.Background(), samplePO, []EntryEdit{
+		{Msgid: "Hello", Msgstr: &hola, Comments: []string{"reviewed"}},
+		{ID: fileID, MsgstrPlural: []string{"Fichero", "Ficheros"}, AddFlags: []string{"fuzzy"}},
+		{Msgctxt: &menu, Msgid: "Open", AddFlags: []string{"no-c-format"}},
+	}, UpdateOptions{BaseHash: ContentHash(samplePO)})
+	if err != nil {
+		t.Fatalf("update returned error: %v", err)
+	}
+	if res.Updated != 3 || res.Hash != ContentHash(res.Content) {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	out, err := ParseCatalog(res.Content)
+	if err != nil {
+		t.Fatalf("output does not parse: %v", err)
+	}
+	if e := out.Find(nil, "Hello"); e.Msgstr[0] != "¡Hola!" || len(e.Comments) != 1 || e.Comments[0] != "reviewed" {
+		t.Fatalf("unexpected Hello entry: %+v", e)
+	}
+	if e := out.Find(nil, "File"); e.Msgstr[1] != "Ficheros" || !e.IsFuzzy() || e.ID() != fileID {
+		t.Fatalf("unexpected File entry: %+v", e)
+	}
+	if e := out.Find(&menu, "Open"); !e.HasFlag("no-c-format") {
+		t.Fatalf("flag not added: %+v", e)
+	}
+
+	for _, want := range []string{"--- a/messages.po", "-msgstr \"Hola\"", "+msgstr \"¡Hola!\"", "+# reviewed", "+msgstr[1] \"Ficheros\""} {
+		if !strings.Contains(res.Diff, want) {
+			t.Fatalf("diff lacks %q:\n%s", want, res.Diff)
+		}
+	}
+}
+
+func TestUpdateEntriesRejectsBatch(t *testing.T) {
+	svc := NewService()
+	hola := "Hola"
+
+	if _, err := svc.UpdateEntries(context.Background(), samplePO, []EntryEdit{{Msgid: "Hello", Msgstr: &hola}}, UpdateOptions{BaseHash: "0000"}); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Fatalf("expected stale hash error, got %v", err)
+	}
+
+	cases := map[string]EntryEdit{
+		"no entry with msgid":          {Msgid: "Missing", Msgstr: &hola},
+		"no entry with id":             {ID: "000000000000", Msgstr: &hola},
+		"use msgstr_plural":            {Msgid: "File", Msgstr: &hola},
+		"needs 2 plural forms":         {Msgid: "File", MsgstrPlural: []string{"x"}},
+		"no entry with msgid \"Open\"": {Msgid: "Open", Msgstr: &hola},
+	}
+	for want, edit := range cases {
+		_, err := svc.UpdateEntries(context.Background(), samplePO, []EntryEdit{{Msgid: "Hello", Msgstr: &hola}, edit}, UpdateOptions{})
+		if err == nil || !strings.Contains(err.Error(), want) || !strings.HasPrefix(err.Error(), "edit 2:") {
+			t.Fatalf("expected %q error, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+// Package workspace confines the files the server reads and writes to a set
+// of root directories.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MaxFileSize is the largest file Read accepts.
+const MaxFileSize = 32 << 20
+
+// ErrNoRoots is returned when a path is used before any root is known.
+var ErrNoRoots = errors.New("no workspace roots: start the server with -root or use a client that advertises roots")
+
+// Workspace resolves paths against its roots and rejects anything outside
+// them, including paths that escape through ".." or symbolic links.
+//
+// Roots come from the server configuration or, when none is configured, from
+// the client (MCP roots/list). Configured roots always win so that a client
+// cannot widen what the operator allowed.
+type Workspace struct {
+	mu          sync.RWMutex
+	configured  []string
+	clientRoots []string
+}
+
+// New returns a Workspace confined to the given directories.
+func New(roots ...string) (*Workspace, error) {
+	w := &Workspace{}
+	for _, r := range roots {
+		dir, err := resolveRoot(r)
+		if err != nil {
+			return nil, err
+		}
+		w.configured = append(w.configured, dir)
+	}
+	return w, nil
+}
+
+// SetClientRoots replaces the roots advertised by the client. Entries may be
+// file:// URIs or plain paths; roots that do not exist are skipped.
+func (w *Workspace) SetClientRoots(roots []string) error {
+	var dirs []string
+	var errs []error
+	for _, r := range roots {
+		if strings.HasPrefix(r, "file://") {
+			u, err := url.Parse(r)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("root %q: %w", r, err))
+				continue
+			}
+			r = u.Path
+		} else if strings.Contains(r, "://") {
+			errs = append(errs, fmt.Errorf("root %q: only file:// roots are supported", r))
+			continue
+		}
+		dir, err := resolveRoot(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	w.mu.Lock()
+	w.clientRoots = dirs
+	w.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Roots returns the roots in effect.
+func (w *Workspace) Roots() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if len(w.configured) > 0 {
+		return append([]string(nil), w.configured...)
+	}
+	return append([]string(nil), w.clientRoots...)
+}
+
+// Resolve returns the absolute, symlink-free form of path after checking it
+// lies inside a root. Relative paths are taken from the first root. The file
+// itself need not exist, but its parent directory must.
+func (w *Workspace) Resolve(path string) (string, error) {
+	roots := w.Roots()
+	if len(roots) == 0 {
+		return "", ErrNoRoots
+	}
+	if path == "" {
+		return "", errors.New("empty path")
+	}
+	if strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("invalid path %q", path)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(roots[0], path)
+	}
+	path = filepath.Clean(path)
+
+	real, err := evalExisting(path)
+	if err != nil {
+		return "", err
+	}
+	for _, root := range roots {
+		if within(root, real) {
+			return real, nil
+		}
+	}
+	return "", fmt.Errorf("path %q is outside the workspace roots", path)
+}
+
+// Read returns the content of a file inside the workspace, byte for byte.
+func (w *Workspace) Read(path string) ([]byte, error) {
+	real, err := w.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > MaxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, MaxFileSize)
+	}
+	return os.ReadFile(real)
+}
+
+// WriteOptions controls Write.
+type WriteOptions struct {
+	// Backup keeps the file being replaced as <name>.bak.
+	Backup bool
+}
+
+// Written describes a completed Write.
+type Written struct {
+	Path   string
+	Backup string
+}
+
+// Write stores data in a file inside the workspace. The content goes to a
+// temp file in the same directory that is renamed over the target, so readers
+// never see a partial file.
+func (w *Workspace) Write(path string, data []byte, opts WriteOptions) (*Written, error) {
+	real, err := w.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	mode := fs.FileMode(0o644)
+	info, err := os.Stat(real)
+	exists := err == nil
+	if exists {
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", path)
+		}
+		mode = info.Mode().Perm()
+	}
+
+	res := &Written{Path: real}
+	if opts.Backup && exists {
+		res.Backup = real + ".bak"
+		if err := backup(real, res.Backup); err != nil {
+			return nil, fmt.Errorf("backup %s: %w", path, err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(real), "."+filepath.Base(real)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), real); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// IsDir reports whether path is an existing directory inside the workspace.
+func (w *Workspace) IsDir(path string) bool {
+	real, err := w.Resolve(path)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(real)
+	return err == nil && info.IsDir()
+}
+
+// IsFile reports whether path is an existing regular file inside the
+// workspace.
+func (w *Workspace) IsFile(path string) bool {
+	real, err := w.Resolve(path)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(real)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// MaxFindResults caps the files Find returns.
+const MaxFindResults = 1000
+
+// Found is the result of Find.
+type Found struct {
+	// Base is the directory the search started from.
+	Base  string
+	Files []string
+	// Unreadable lists the directories that could not be searched; they are
+	// skipped rather than failing the whole search.
+	Unreadable []string
+}
+
+// Find returns the regular files inside the workspace selected by pattern,
+// and the directory the search started from. pattern is a directory, whose
+// files with extension ext are returned recursively, or a glob where "*" and
+// "?" match within a path element and "**" matches any number of them.
+// Hidden directories and node_modules are not searched, and symbolic links
+// are only followed when they stay inside the workspace.
+func (w *Workspace) Find(pattern, ext string) (*Found, error) {
+	base, glob := pattern, ""
+	if !w.IsDir(pattern) {
+		base, glob = splitGlob(filepath.ToSlash(pattern))
+	}
+	root, err := w.Resolve(base)
+	if err != nil {
+		return nil, err
+	}
+	var globParts []string
+	if glob != "" {
+		globParts = strings.Split(glob, "/")
+	}
+
+	found := &Found{Base: root}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root || d == nil || !d.IsDir() {
+				return err
+			}
+			found.Unreadable = append(found.Unreadable, p)
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if globParts != nil {
+			if !matchGlob(globParts, strings.Split(filepath.ToSlash(rel), "/")) {
+				return nil
+			}
+		} else if !strings.EqualFold(filepath.Ext(p), ext) {
+			return nil
+		}
+		real, err := w.Resolve(p)
+		if err != nil {
+			return nil // a symlink leaving the workspace
+		}
+		if info, err := os.Stat(real); err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if len(found.Files) == MaxFindResults {
+			return fmt.Errorf("%s matches more than %d files", pattern, MaxFindResults)
+		}
+		found.Files = append(found.Files, real)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// splitGlob cuts a slash-separated pattern before its first element holding
+// a glob metacharacter.
+func splitGlob(pattern string) (base, glob string) {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			base = strings.Join(parts[:i], "/")
+			if base == "" && strings.HasPrefix(pattern, "/") {
+				base = "/"
+			} else if base == "" {
+				base = "."
+			}
+			return base, strings.Join(parts[i:], "/")
+		}
+	}
+	return pattern, ""
+}
+
+// matchGlob matches path elements against pattern elements; "**" matches
+// zero or more elements.
+func matchGlob(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		return matchGlob(pattern[1:], name) || (len(name) > 0 && matchGlob(pattern, name[1:]))
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], name[0])
+	return err == nil && ok && matchGlob(pattern[1:], name[1:])
+}
+
+// backup preserves the current content of path at dst, as a hard link when
+// possible and as a copy otherwise.
+func backup(path, dst string) error {
+	if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Link(path, dst); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+func resolveRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("root %q: %w", dir, err)
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("root %q: %w", dir, err)
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return "", fmt.Errorf("root %q: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("root %q is not a directory", dir)
+	}
+	return real, nil
+}
+
+// evalExisting resolves symlinks in path. A missing final element is allowed
+// (a file about to be created); its parent must exist.
+func evalExisting(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return real, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if _, lerr := os.Lstat(path); lerr == nil {
+		// A dangling symlink: refuse rather than create its target.
+		return "", fmt.Errorf("%s is a broken symbolic link", path)
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
+
+// within reports whether path is root or lies below it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
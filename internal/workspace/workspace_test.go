@@ -0,0 +1,214 @@
+package workspace
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T) (root, outside string) {
+	t.Helper()
+	base := t.TempDir()
+	root = filepath.Join(base, "root")
+	outside = filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "languages"), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "languages", "es.po"), []byte("msgid \"\"\nmsgstr \"\"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.po"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return root, outside
+}
+
+func TestReadWriteInsideRoot(t *testing.T) {
+	root, _ := setup(t)
+	ws, err := New(root)
+	if err != nil {
+		t.Fatalf("new returned error: %v", err)
+	}
+
+	data, err := ws.Read("languages/es.po")
+	if err != nil || !strings.HasPrefix(string(data), "msgid") {
+		t.Fatalf("relative read failed: %q, %v", data, err)
+	}
+	if _, err := ws.Read(filepath.Join(root, "languages", "es.po")); err != nil {
+		t.Fatalf("absolute read failed: %v", err)
+	}
+
+	written, err := ws.Write("languages/es.mo", []byte("mo"), WriteOptions{})
+	if err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	if filepath.Base(written.Path) != "es.mo" || written.Backup != "" {
+		t.Fatalf("unexpected write result %+v", written)
+	}
+	if _, err := ws.Write("missing/dir/es.mo", []byte("mo"), WriteOptions{}); err == nil {
+		t.Fatalf("expected error writing into a missing directory")
+	}
+}
+
+func TestRejectsEscapes(t *testing.T) {
+	root, outside := setup(t)
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "new.po"), filepath.Join(root, "dangling.po")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	ws, _ := New(root)
+
+	for _, path := range []string{
+		"../outside/secret.po",
+		"languages/../../outside/secret.po",
+		filepath.Join(outside, "secret.po"),
+		"link/secret.po",
+		"link/new.po",
+		"dangling.po",
+	} {
+		if _, err := ws.Read(path); err == nil {
+			t.Fatalf("expected %s to be rejected for reading", path)
+		}
+		if _, err := ws.Write(path, []byte("x"), WriteOptions{}); err == nil {
+			t.Fatalf("expected %s to be rejected for writing", path)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(outside, "secret.po")); string(data) != "secret" {
+		t.Fatalf("file outside the root was modified")
+	}
+}
+
+func TestClientRootsAndPrecedence(t *testing.T) {
+	root, outside := setup(t)
+
+	ws, _ := New()
+	if _, err := ws.Read("languages/es.po"); !errors.Is(err, ErrNoRoots) {
+		t.Fatalf("expected ErrNoRoots, got %v", err)
+	}
+	if err := ws.SetClientRoots([]string{"file://" + root}); err != nil {
+		t.Fatalf("set client roots returned error: %v", err)
+	}
+	if _, err := ws.Read("languages/es.po"); err != nil {
+		t.Fatalf("read under client root failed: %v", err)
+	}
+
+	configured, _ := New(root)
+	_ = configured.SetClientRoots([]string{outside})
+	if _, err := configured.Read(filepath.Join(outside, "secret.po")); err == nil {
+		t.Fatalf("client roots must not widen configured roots")
+	}
+}
+
+func TestReadsBinaryFiles(t *testing.T) {
+	root, _ := setup(t)
+	mo := []byte{0xde, 0x12, 0x04, 0x95, 0, 0}
+	if err := os.WriteFile(filepath.Join(root, "es.mo"), mo, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	ws, _ := New(root)
+	if data, err := ws.Read("es.mo"); err != nil || !bytes.Equal(data, mo) {
+		t.Fatalf("Read = %v, %v; want the exact bytes", data, err)
+	}
+}
+
+func TestWriteReplacesAtomicallyWithBackup(t *testing.T) {
+	root, _ := setup(t)
+	ws, _ := New(root)
+
+	written, err := ws.Write("languages/es.po", []byte("new"), WriteOptions{Backup: true})
+	if err != nil {
+		t.Fatalf("write returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(written.Path); string(data) != "new" {
+		t.Fatalf("file not replaced: %q", data)
+	}
+	if data, _ := os.ReadFile(written.Backup); !strings.HasPrefix(string(data), "msgid") {
+		t.Fatalf("backup does not hold the old content: %q", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(root, "languages"))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Fatalf("temp file left behind: %s", e.Name())
+		}
+	}
+	if !ws.IsDir("languages") || ws.IsDir("languages/es.po") {
+		t.Fatalf("unexpected IsDir results")
+	}
+	if !ws.IsFile("languages/es.po") || ws.IsFile("languages") || ws.IsFile("languages/missing.po") {
+		t.Fatalf("unexpected IsFile results")
+	}
+}
+
+func TestFindDirectoryAndGlob(t *testing.T) {
+	root, outside := setup(t)
+	for _, name := range []string{"languages/fr_FR.po", "languages/sub/de_DE.po", "languages/readme.txt", "node_modules/x/it.po", ".git/es.po"} {
+		p := filepath.Join(root, name)
+		_ = os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	_ = os.Symlink(filepath.Join(outside, "secret.po"), filepath.Join(root, "languages", "secret.po"))
+	ws, _ := New(root)
+
+	names := func(files []string) []string {
+		var out []string
+		for _, f := range files {
+			rel, _ := filepath.Rel(root, f)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return out
+	}
+
+	found, err := ws.Find("languages", ".po")
+	if err != nil {
+		t.Fatalf("find returned error: %v", err)
+	}
+	if found.Base != filepath.Join(root, "languages") || strings.Join(names(found.Files), ",") != "languages/es.po,languages/fr_FR.po,languages/sub/de_DE.po" {
+		t.Fatalf("unexpected directory results %s %v", found.Base, names(found.Files))
+	}
+
+	found, err = ws.Find("**/*_FR.po", ".po")
+	if err != nil || strings.Join(names(found.Files), ",") != "languages/fr_FR.po" {
+		t.Fatalf("unexpected glob results %v, %v", found, err)
+	}
+	found, _ = ws.Find("languages/*.po", ".po")
+	if strings.Join(names(found.Files), ",") != "languages/es.po,languages/fr_FR.po" {
+		t.Fatalf("single star must not cross directories: %v", names(found.Files))
+	}
+	if _, err := ws.Find("../outside/*.po", ".po"); err == nil {
+		t.Fatalf("expected a glob outside the root to be rejected")
+	}
+}
+
+func TestFindSkipsUnreadableDirectories(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+	root, _ := setup(t)
+	locked := filepath.Join(root, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(locked, 0o755) })
+	ws, _ := New(root)
+
+	found, err := ws.Find(".", ".po")
+	if err != nil {
+		t.Fatalf("find returned error: %v", err)
+	}
+	if len(found.Files) != 1 || len(found.Unreadable) != 1 || found.Unreadable[0] != locked {
+		t.Fatalf("unexpected results %v, unreadable %v", found.Files, found.Unreadable)
+	}
+}
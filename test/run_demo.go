@@ -49,14 +49,14 @@ func main() {
 		}
 
 		// Test Compile
-		result, err := svc.Compile(ctx, string(content), "base64")
+		result, err := svc.Compile(ctx, string(content), po.CompileOptions{Return: "base64"})
 		if err != nil {
 			fmt.Printf("Compile error: %v\n", err)
 		} else {
 			fmt.Printf("Compiled: %d bytes (base64)\n", len(result.Base64))
 
 			// Also save to .mo file
-			moResult, err := svc.Compile(ctx, string(content), "path")
+			moResult, err := svc.Compile(ctx, string(content), po.CompileOptions{Return: "path"})
 			if err != nil {
 				fmt.Printf("Compile to path error: %v\n", err)
 			} else {